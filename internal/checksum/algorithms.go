@@ -0,0 +1,78 @@
+// Copyright (C) 2022 Mya Pitzeruse
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package checksum
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"sort"
+	"strings"
+
+	"github.com/cespare/xxhash/v2"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/sha3"
+	"lukechampine.com/blake3"
+)
+
+var algorithms = map[string]func() hash.Hash{
+	"md5":      md5.New,
+	"sha1":     sha1.New,
+	"sha256":   sha256.New,
+	"sha512":   sha512.New,
+	"sha3":     sha3.New256,
+	"sha3-256": sha3.New256,
+	"sha3-512": sha3.New512,
+	"blake2b": func() hash.Hash {
+		h, _ := blake2b.New512(nil)
+		return h
+	},
+	"blake3": func() hash.Hash {
+		return blake3.New(32, nil)
+	},
+	"crc32": func() hash.Hash {
+		return crc32.NewIEEE()
+	},
+	"xxhash": func() hash.Hash {
+		return xxhash.New()
+	},
+}
+
+// Algorithms returns the names of the supported hash algorithms.
+func Algorithms() []string {
+	names := make([]string, 0, len(algorithms))
+	for name := range algorithms {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// New returns a new hash for the named algorithm.
+func New(alg string) (hash.Hash, error) {
+	fn, ok := algorithms[strings.ToLower(alg)]
+	if !ok {
+		return nil, fmt.Errorf("unrecognized algorithm: %s (available: %s)", alg, strings.Join(Algorithms(), ", "))
+	}
+
+	return fn(), nil
+}
@@ -0,0 +1,215 @@
+// Copyright (C) 2022 Mya Pitzeruse
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package checksum
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/sync/errgroup"
+
+	"go.pitz.tech/em/internal/encoding"
+
+	"go.pitz.tech/lib/flagset"
+)
+
+type HashConfig struct {
+	Alg         string `json:"alg"         alias:"a" usage:"the hash algorithm (sha256, sha512, sha3, blake2b, blake3, md5, crc32, xxhash)" default:"sha256"`
+	Out         string `json:"out"         alias:"o" usage:"the encoding used to print digests (hex, base64, base32, phone, ...)" default:"hex"`
+	Check       bool   `json:"check"       alias:"c" usage:"read checksums from the provided manifests and verify them"`
+	Concurrency int    `json:"concurrency" usage:"the maximum number of files hashed at once" default:"8"`
+}
+
+type result struct {
+	name   string
+	digest string
+	err    error
+}
+
+var (
+	hashConfig = &HashConfig{}
+
+	Command = &cli.Command{
+		Name:  "hash",
+		Usage: "Compute and verify file checksums.",
+		UsageText: strings.Join([]string{
+			"em hash [options] [files...]",
+			"em hash --alg blake3 --out base64 go.mod go.sum",
+			"em hash --check SHA256SUMS",
+		}, "\n"),
+		Flags: flagset.ExtractPrefix("em", hashConfig),
+		Action: func(ctx *cli.Context) error {
+			cfg := hashConfig
+
+			if _, err := New(cfg.Alg); err != nil {
+				return err
+			}
+
+			files := ctx.Args().Slice()
+			if len(files) == 0 {
+				files = []string{"-"}
+			}
+
+			if cfg.Check {
+				return check(ctx, files)
+			}
+
+			failed := 0
+			for _, r := range sumAll(ctx, files) {
+				if r.err != nil {
+					failed++
+					_, _ = fmt.Fprintf(ctx.App.ErrWriter, "%s: %v\n", r.name, r.err)
+
+					continue
+				}
+
+				_, _ = fmt.Fprintf(ctx.App.Writer, "%s  %s\n", r.digest, r.name)
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("failed to hash %d of %d files", failed, len(files))
+			}
+
+			return nil
+		},
+		HideHelpCommand: true,
+	}
+)
+
+// sumAll hashes each of the files concurrently, returning the results in the same order as the files.
+func sumAll(ctx *cli.Context, files []string) []result {
+	results := make([]result, len(files))
+
+	group := &errgroup.Group{}
+	if hashConfig.Concurrency > 0 {
+		group.SetLimit(hashConfig.Concurrency)
+	}
+
+	for i, file := range files {
+		i, file := i, file
+
+		group.Go(func() error {
+			results[i] = result{name: file}
+			results[i].digest, results[i].err = sum(ctx, file)
+
+			return nil
+		})
+	}
+
+	_ = group.Wait()
+
+	return results
+}
+
+// sum computes the encoded digest of a single file. The file "-" refers to stdin.
+func sum(ctx *cli.Context, file string) (string, error) {
+	h, err := New(hashConfig.Alg)
+	if err != nil {
+		return "", err
+	}
+
+	reader := ctx.App.Reader
+	if file != "-" {
+		handle, err := os.Open(file)
+		if err != nil {
+			return "", err
+		}
+
+		defer handle.Close()
+		reader = handle
+	}
+
+	if _, err = io.Copy(h, reader); err != nil {
+		return "", err
+	}
+
+	return encoding.EncodeToString(encoding.EncodeConfig{Out: hashConfig.Out}, h.Sum(nil))
+}
+
+// check verifies the checksums listed in each manifest. Manifests use the format written by sha256sum and friends,
+// where each line contains a digest followed by two spaces (or a space and an asterisk) and the file name.
+func check(ctx *cli.Context, manifests []string) error {
+	var expected, files []string
+
+	for _, manifest := range manifests {
+		reader := ctx.App.Reader
+		if manifest != "-" {
+			handle, err := os.Open(manifest)
+			if err != nil {
+				return err
+			}
+
+			defer handle.Close()
+			reader = handle
+		}
+
+		scanner := bufio.NewScanner(reader)
+		for num := 1; scanner.Scan(); num++ {
+			line := strings.TrimRight(scanner.Text(), "\r")
+			if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+
+			idx := strings.IndexByte(line, ' ')
+			if idx < 0 || idx+2 > len(line) || (line[idx+1] != ' ' && line[idx+1] != '*') {
+				return fmt.Errorf("%s:%d: improperly formatted checksum line", manifest, num)
+			}
+
+			expected = append(expected, line[:idx])
+			files = append(files, line[idx+2:])
+		}
+
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+	}
+
+	mismatched, unreadable := 0, 0
+	for i, r := range sumAll(ctx, files) {
+		status := "OK"
+
+		switch {
+		case r.err != nil:
+			status = "FAILED open or read"
+			unreadable++
+		case r.digest != expected[i] && !(hashConfig.Out == "hex" && strings.EqualFold(r.digest, expected[i])):
+			status = "FAILED"
+			mismatched++
+		}
+
+		_, _ = fmt.Fprintf(ctx.App.Writer, "%s: %s\n", r.name, status)
+	}
+
+	var problems []string
+	if mismatched > 0 {
+		problems = append(problems, fmt.Sprintf("%d of %d computed checksums did not match", mismatched, len(files)))
+	}
+
+	if unreadable > 0 {
+		problems = append(problems, fmt.Sprintf("%d of %d listed files could not be read", unreadable, len(files)))
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, ", "))
+	}
+
+	return nil
+}
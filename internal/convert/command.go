@@ -0,0 +1,104 @@
+// Copyright (C) 2022 Mya Pitzeruse
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package convert
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"go.pitz.tech/lib/flagset"
+)
+
+type ConvertConfig struct {
+	In     string `json:"in"     alias:"i" usage:"the input format (json, jsonl, yaml, toml, cbor, msgpack)"  default:"json"`
+	Out    string `json:"out"    alias:"o" usage:"the output format (json, jsonl, yaml, toml, cbor, msgpack)" default:"json"`
+	Select string `json:"select" alias:"s" usage:"extract a subtree using a path expression (.items[0].name, .items[].name)"`
+}
+
+var (
+	convertConfig = &ConvertConfig{}
+
+	Command = &cli.Command{
+		Name:      "convert",
+		Usage:     "Convert structured documents between formats.",
+		UsageText: "em convert [options] [file]",
+		Flags:     flagset.ExtractPrefix("em", convertConfig),
+		Action: func(ctx *cli.Context) (err error) {
+			cfg := convertConfig
+
+			selector, err := ParseSelector(cfg.Select)
+			if err != nil {
+				return err
+			}
+
+			reader := ctx.App.Reader
+			if ctx.NArg() > 0 {
+				handle, err := os.Open(ctx.Args().Get(0))
+				if err != nil {
+					return err
+				}
+
+				defer handle.Close()
+				reader = handle
+			}
+
+			decoder, err := NewDecoder(cfg.In, bufio.NewReader(reader))
+			if err != nil {
+				return err
+			}
+
+			writer := bufio.NewWriter(ctx.App.Writer)
+			defer writer.Flush()
+
+			encoder, err := NewEncoder(cfg.Out, writer)
+			if err != nil {
+				return err
+			}
+
+			defer func() {
+				if closeErr := encoder.Close(); err == nil {
+					err = closeErr
+				}
+			}()
+
+			for {
+				doc, err := decoder.Decode()
+				switch {
+				case errors.Is(err, io.EOF):
+					return nil
+				case err != nil:
+					return err
+				}
+
+				values, err := selector.Select(doc)
+				if err != nil {
+					return err
+				}
+
+				for _, value := range values {
+					if err = encoder.Encode(value); err != nil {
+						return err
+					}
+				}
+			}
+		},
+		HideHelpCommand: true,
+	}
+)
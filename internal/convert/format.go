@@ -0,0 +1,206 @@
+// Copyright (C) 2022 Mya Pitzeruse
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package convert
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+	"gopkg.in/yaml.v3"
+)
+
+// Formats lists the supported structured data formats.
+var Formats = []string{"json", "jsonl", "yaml", "toml", "cbor", "msgpack"}
+
+// Decoder reads successive documents from a stream, returning io.EOF once the stream is exhausted.
+type Decoder interface {
+	Decode() (any, error)
+}
+
+// Encoder writes successive documents to a stream. Encoders must be closed once all documents have been written.
+type Encoder interface {
+	Encode(any) error
+	Close() error
+}
+
+// NewDecoder returns a decoder for the named format.
+func NewDecoder(format string, reader io.Reader) (Decoder, error) {
+	switch format {
+	case "json", "jsonl":
+		dec := json.NewDecoder(reader)
+		dec.UseNumber()
+
+		return decodeFunc(func(v *any) error { return dec.Decode(v) }), nil
+	case "yaml", "yml":
+		dec := yaml.NewDecoder(reader)
+		return decodeFunc(func(v *any) error { return dec.Decode(v) }), nil
+	case "toml":
+		return &tomlDecoder{reader: reader}, nil
+	case "cbor":
+		dec := cbor.NewDecoder(reader)
+		return decodeFunc(func(v *any) error { return dec.Decode(v) }), nil
+	case "msgpack":
+		dec := msgpack.NewDecoder(bufio.NewReader(reader))
+		return decodeFunc(func(v *any) error { return dec.Decode(v) }), nil
+	default:
+		return nil, fmt.Errorf("unrecognized input format: %s (available: %s)", format, strings.Join(Formats, ", "))
+	}
+}
+
+// NewEncoder returns an encoder for the named format.
+func NewEncoder(format string, writer io.Writer) (Encoder, error) {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(writer)
+		enc.SetIndent("", "  ")
+
+		return encodeFunc(enc.Encode), nil
+	case "jsonl":
+		return encodeFunc(json.NewEncoder(writer).Encode), nil
+	case "yaml", "yml":
+		enc := yaml.NewEncoder(writer)
+		enc.SetIndent(2)
+
+		return enc, nil
+	case "toml":
+		return &tomlEncoder{writer: writer}, nil
+	case "cbor":
+		return encodeFunc(cbor.NewEncoder(writer).Encode), nil
+	case "msgpack":
+		return encodeFunc(msgpack.NewEncoder(writer).Encode), nil
+	default:
+		return nil, fmt.Errorf("unrecognized output format: %s (available: %s)", format, strings.Join(Formats, ", "))
+	}
+}
+
+type decodeFunc func(*any) error
+
+func (fn decodeFunc) Decode() (v any, err error) {
+	if err = fn(&v); err != nil {
+		return nil, err
+	}
+
+	return normalize(v), nil
+}
+
+type encodeFunc func(any) error
+
+func (fn encodeFunc) Encode(v any) error {
+	return fn(v)
+}
+
+func (fn encodeFunc) Close() error {
+	return nil
+}
+
+// tomlDecoder reads a single document, since TOML has no notion of a document stream.
+type tomlDecoder struct {
+	reader io.Reader
+	done   bool
+}
+
+func (d *tomlDecoder) Decode() (any, error) {
+	if d.done {
+		return nil, io.EOF
+	}
+
+	d.done = true
+
+	data, err := io.ReadAll(d.reader)
+	if err != nil {
+		return nil, err
+	}
+
+	v := make(map[string]any)
+	if err = toml.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+
+	return normalize(v), nil
+}
+
+// tomlEncoder writes each document as a table. Documents after the first are separated by a blank line.
+type tomlEncoder struct {
+	writer io.Writer
+	count  int
+}
+
+func (e *tomlEncoder) Encode(v any) error {
+	if _, ok := v.(map[string]any); !ok {
+		return fmt.Errorf("toml documents must be tables, got %T", v)
+	}
+
+	if e.count > 0 {
+		if _, err := e.writer.Write([]byte("\n")); err != nil {
+			return err
+		}
+	}
+
+	e.count++
+
+	return toml.NewEncoder(e.writer).Encode(v)
+}
+
+func (e *tomlEncoder) Close() error {
+	return nil
+}
+
+// normalize converts the values produced by the various decoders into a common representation so they can be
+// written by any encoder. Maps are keyed by strings and JSON numbers are converted into integers or floats.
+func normalize(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for key, value := range t {
+			t[key] = normalize(value)
+		}
+
+		return t
+	case map[any]any:
+		m := make(map[string]any, len(t))
+		for key, value := range t {
+			m[fmt.Sprint(key)] = normalize(value)
+		}
+
+		return m
+	case []any:
+		for i, value := range t {
+			t[i] = normalize(value)
+		}
+
+		return t
+	case json.Number:
+		if i, err := strconv.ParseInt(string(t), 10, 64); err == nil {
+			return i
+		}
+
+		if u, err := strconv.ParseUint(string(t), 10, 64); err == nil {
+			return u
+		}
+
+		f, _ := t.Float64()
+
+		return f
+	default:
+		return v
+	}
+}
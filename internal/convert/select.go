@@ -0,0 +1,153 @@
+// Copyright (C) 2022 Mya Pitzeruse
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package convert
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type step struct {
+	key     *string
+	index   *int
+	iterate bool
+}
+
+// Selector extracts values from a document using a small subset of jq path expressions. Supported steps include
+// object keys (.name or ["name"]), array indices ([0] or [-1] to count from the end), and iteration ([] or [*])
+// which fans out over every element of an array or object (in key order).
+type Selector []step
+
+// ParseSelector parses the provided expression. An empty expression (or ".") selects the entire document.
+func ParseSelector(expr string) (Selector, error) {
+	var selector Selector
+
+	rest := strings.TrimPrefix(strings.TrimSpace(expr), "$")
+
+	for len(rest) > 0 {
+		switch {
+		case rest == ".":
+			rest = ""
+		case rest[0] == '.' && len(rest) > 1 && rest[1] == '[':
+			rest = rest[1:]
+		case rest[0] == '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+
+			key := rest[1 : end+1]
+			if key == "" {
+				return nil, fmt.Errorf("empty key in selector %q", expr)
+			}
+
+			selector = append(selector, step{key: &key})
+			rest = rest[end+1:]
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated bracket in selector %q", expr)
+			}
+
+			inner := strings.TrimSpace(rest[1:end])
+			rest = rest[end+1:]
+
+			switch {
+			case inner == "" || inner == "*":
+				selector = append(selector, step{iterate: true})
+			case inner[0] == '"' || inner[0] == '\'':
+				key, err := strconv.Unquote(`"` + strings.Trim(inner, `"'`) + `"`)
+				if err != nil {
+					return nil, fmt.Errorf("invalid key %s in selector %q", inner, expr)
+				}
+
+				selector = append(selector, step{key: &key})
+			default:
+				index, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("invalid index %s in selector %q", inner, expr)
+				}
+
+				selector = append(selector, step{index: &index})
+			}
+		default:
+			return nil, fmt.Errorf("unexpected %q in selector %q", rest[0], expr)
+		}
+	}
+
+	return selector, nil
+}
+
+// Select applies the selector to the document, returning every matching value. Missing keys and out of range
+// indices produce nil, matching jq.
+func (s Selector) Select(doc any) ([]any, error) {
+	values := []any{doc}
+
+	for _, step := range s {
+		next := make([]any, 0, len(values))
+
+		for _, value := range values {
+			switch t := value.(type) {
+			case nil:
+				next = append(next, nil)
+			case map[string]any:
+				switch {
+				case step.key != nil:
+					next = append(next, t[*step.key])
+				case step.iterate:
+					keys := make([]string, 0, len(t))
+					for key := range t {
+						keys = append(keys, key)
+					}
+
+					sort.Strings(keys)
+
+					for _, key := range keys {
+						next = append(next, t[key])
+					}
+				default:
+					return nil, fmt.Errorf("cannot index object with %d", *step.index)
+				}
+			case []any:
+				switch {
+				case step.index != nil:
+					index := *step.index
+					if index < 0 {
+						index += len(t)
+					}
+
+					if index < 0 || index >= len(t) {
+						next = append(next, nil)
+					} else {
+						next = append(next, t[index])
+					}
+				case step.iterate:
+					next = append(next, t...)
+				default:
+					return nil, fmt.Errorf("cannot index array with %q", *step.key)
+				}
+			default:
+				return nil, fmt.Errorf("cannot select from %T", value)
+			}
+		}
+
+		values = next
+	}
+
+	return values, nil
+}
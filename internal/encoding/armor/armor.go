@@ -0,0 +1,73 @@
+// Copyright (C) 2022 Mya Pitzeruse
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package armor
+
+import (
+	"encoding/pem"
+	"fmt"
+
+	"go.pitz.tech/em/internal/encoding/buffered"
+)
+
+// Encode returns a function that wraps data in a PEM block of the provided type.
+func Encode(blockType string) buffered.Func {
+	return func(data []byte) ([]byte, error) {
+		if blockType == "" {
+			return nil, fmt.Errorf("a block type is required when writing pem")
+		}
+
+		return pem.EncodeToMemory(&pem.Block{
+			Type:  blockType,
+			Bytes: data,
+		}), nil
+	}
+}
+
+// Decode returns a function that strips the PEM armor from data. When blockType is provided, only blocks of that type
+// are considered. The index selects which of the remaining blocks is returned.
+func Decode(blockType string, index int) buffered.Func {
+	return func(data []byte) ([]byte, error) {
+		matched := 0
+
+		for rest := data; len(rest) > 0; {
+			var block *pem.Block
+
+			block, rest = pem.Decode(rest)
+			if block == nil {
+				break
+			}
+
+			if blockType != "" && block.Type != blockType {
+				continue
+			}
+
+			if matched == index {
+				return block.Bytes, nil
+			}
+
+			matched++
+		}
+
+		switch {
+		case matched == 0 && blockType != "":
+			return nil, fmt.Errorf("no %s pem blocks found", blockType)
+		case matched == 0:
+			return nil, fmt.Errorf("no pem blocks found")
+		default:
+			return nil, fmt.Errorf("pem block index %d out of range, found %d", index, matched)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright (C) 2022 Mya Pitzeruse
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package base58
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/big"
+
+	"go.pitz.tech/em/internal/encoding/buffered"
+)
+
+// Alphabet is the bitcoin base58 alphabet. It omits 0, O, I, and l to avoid visual ambiguity.
+const Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+var (
+	radix    = big.NewInt(58)
+	decoding [256]int8
+)
+
+func init() {
+	for i := range decoding {
+		decoding[i] = -1
+	}
+
+	for i := 0; i < len(Alphabet); i++ {
+		decoding[Alphabet[i]] = int8(i)
+	}
+}
+
+// Encode returns the base58 representation of src. Leading zero bytes are preserved as leading '1' characters.
+func Encode(src []byte) []byte {
+	zeros := 0
+	for zeros < len(src) && src[zeros] == 0 {
+		zeros++
+	}
+
+	num := new(big.Int).SetBytes(src[zeros:])
+	mod := new(big.Int)
+
+	encoded := make([]byte, 0, len(src)*138/100+1)
+	for num.Sign() > 0 {
+		num.DivMod(num, radix, mod)
+		encoded = append(encoded, Alphabet[mod.Int64()])
+	}
+
+	for i := 0; i < zeros; i++ {
+		encoded = append(encoded, Alphabet[0])
+	}
+
+	for i, j := 0, len(encoded)-1; i < j; i, j = i+1, j-1 {
+		encoded[i], encoded[j] = encoded[j], encoded[i]
+	}
+
+	return encoded
+}
+
+// Decode parses the base58 encoded src. Surrounding whitespace is ignored.
+func Decode(src []byte) ([]byte, error) {
+	src = bytes.TrimSpace(src)
+
+	zeros := 0
+	for zeros < len(src) && src[zeros] == Alphabet[0] {
+		zeros++
+	}
+
+	num := new(big.Int)
+	for i := zeros; i < len(src); i++ {
+		digit := decoding[src[i]]
+		if digit < 0 {
+			return nil, fmt.Errorf("illegal base58 data at input byte %d", i)
+		}
+
+		num.Mul(num, radix)
+		num.Add(num, big.NewInt(int64(digit)))
+	}
+
+	return append(make([]byte, zeros), num.Bytes()...), nil
+}
+
+// NewEncoder returns an encoder that writes base58 data to writer. Since base58 is not a block encoding, the
+// data is buffered in memory and only written once the encoder is closed.
+func NewEncoder(writer io.Writer) io.WriteCloser {
+	return buffered.NewEncoder(writer, func(data []byte) ([]byte, error) {
+		return Encode(data), nil
+	})
+}
+
+// NewDecoder returns a decoder that reads base58 data from reader. The entire input is consumed on the first
+// call to Read.
+func NewDecoder(reader io.Reader) io.Reader {
+	return buffered.NewDecoder(reader, Decode)
+}
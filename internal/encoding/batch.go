@@ -0,0 +1,199 @@
+// Copyright (C) 2022 Mya Pitzeruse
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package encoding
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/sync/errgroup"
+)
+
+type batchResult struct {
+	name   string
+	output string
+	err    error
+}
+
+// expand resolves each of the glob patterns, returning the unique set of matching files in the order they were found.
+func expand(patterns []string) ([]string, error) {
+	seen := make(map[string]bool)
+	files := make([]string, 0, len(patterns))
+
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match %q", pattern)
+		}
+
+		for _, match := range matches {
+			if !seen[match] {
+				seen[match] = true
+				files = append(files, match)
+			}
+		}
+	}
+
+	return files, nil
+}
+
+// outputPath determines where the transcoded copy of file is written. When neither an output directory nor a suffix
+// is configured, the file is rewritten in place.
+func outputPath(cfg EncodeConfig, file string) string {
+	dir := filepath.Dir(file)
+	if cfg.OutputDir != "" {
+		dir = cfg.OutputDir
+	}
+
+	return filepath.Join(dir, filepath.Base(file)+cfg.Suffix)
+}
+
+// transcodeFile writes the transcoded contents of file to output. Data is written to a temporary file in the output
+// directory and renamed into place once complete, so inputs transcoded in place are never left half written.
+func transcodeFile(ctx *cli.Context, cfg EncodeConfig, file, output string) (err error) {
+	input, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+
+	defer input.Close()
+
+	info, err := input.Stat()
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		return fmt.Errorf("is a directory")
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(output), "."+filepath.Base(output)+".*")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = temp.Close()
+			_ = os.Remove(temp.Name())
+		}
+	}()
+
+	writer := bufio.NewWriter(temp)
+	if err = transcode(ctx.Context, cfg, input, writer); err != nil {
+		return err
+	}
+
+	if err = writer.Flush(); err != nil {
+		return err
+	}
+
+	// some encoders (such as qr with --qr_file) write elsewhere, which would leave an input rewritten in place empty
+	if output == filepath.Clean(file) && info.Size() > 0 {
+		var written os.FileInfo
+		if written, err = temp.Stat(); err != nil {
+			return err
+		}
+
+		if written.Size() == 0 {
+			return fmt.Errorf("refusing to replace the file with empty output")
+		}
+	}
+
+	if err = temp.Chmod(info.Mode().Perm()); err != nil {
+		return err
+	}
+
+	if err = temp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(temp.Name(), output)
+}
+
+// batch transcodes every file matching the configured globs using a bounded number of workers. Failures are reported
+// per file and do not stop the remaining files from being processed.
+func batch(ctx *cli.Context, cfg EncodeConfig) error {
+	if cfg.QRFile != "" {
+		return fmt.Errorf("--qr_file cannot be used with --file, each file's qr code is written to its own output")
+	}
+
+	files, err := expand(cfg.File.Value())
+	if err != nil {
+		return err
+	}
+
+	if cfg.OutputDir != "" {
+		if err = os.MkdirAll(cfg.OutputDir, 0o755); err != nil {
+			return err
+		}
+	}
+
+	results := make([]batchResult, len(files))
+	written := make(map[string]string, len(files))
+
+	for i, file := range files {
+		results[i] = batchResult{name: file, output: outputPath(cfg, file)}
+
+		if previous, ok := written[results[i].output]; ok {
+			return fmt.Errorf("both %s and %s would be written to %s", previous, file, results[i].output)
+		}
+
+		written[results[i].output] = file
+	}
+
+	group := &errgroup.Group{}
+	if cfg.Concurrency > 0 {
+		group.SetLimit(cfg.Concurrency)
+	}
+
+	for i := range results {
+		r := &results[i]
+
+		group.Go(func() error {
+			r.err = transcodeFile(ctx, cfg, r.name, r.output)
+
+			return nil
+		})
+	}
+
+	_ = group.Wait()
+
+	failed := 0
+	for _, r := range results {
+		if r.err != nil {
+			failed++
+			_, _ = fmt.Fprintf(ctx.App.ErrWriter, "%s: %v\n", r.name, r.err)
+
+			continue
+		}
+
+		_, _ = fmt.Fprintf(ctx.App.Writer, "%s -> %s\n", r.name, r.output)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to transcode %d of %d files", failed, len(files))
+	}
+
+	return nil
+}
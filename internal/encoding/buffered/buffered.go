@@ -0,0 +1,91 @@
+// Copyright (C) 2022 Mya Pitzeruse
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package buffered
+
+import (
+	"bytes"
+	"io"
+)
+
+// Func transforms an entire message at once.
+type Func func([]byte) ([]byte, error)
+
+// NewEncoder returns an encoder for formats that cannot be streamed. Data is buffered in memory and only encoded
+// and written once the encoder is closed.
+func NewEncoder(writer io.Writer, encode Func) *Encoder {
+	return &Encoder{
+		writer: writer,
+		encode: encode,
+	}
+}
+
+type Encoder struct {
+	writer io.Writer
+	encode Func
+	buffer bytes.Buffer
+}
+
+func (e *Encoder) Write(p []byte) (n int, err error) {
+	return e.buffer.Write(p)
+}
+
+func (e *Encoder) Close() error {
+	encoded, err := e.encode(e.buffer.Bytes())
+	if err != nil {
+		return err
+	}
+
+	_, err = e.writer.Write(encoded)
+	return err
+}
+
+// NewDecoder returns a decoder for formats that cannot be streamed. The entire input is consumed and decoded on the
+// first call to Read.
+func NewDecoder(reader io.Reader, decode Func) *Decoder {
+	return &Decoder{
+		reader: reader,
+		decode: decode,
+	}
+}
+
+type Decoder struct {
+	reader  io.Reader
+	decode  Func
+	decoded *bytes.Reader
+}
+
+func (d *Decoder) Read(p []byte) (n int, err error) {
+	if d.decoded == nil {
+		data, err := io.ReadAll(d.reader)
+		if err != nil {
+			return 0, err
+		}
+
+		decoded, err := d.decode(data)
+		if err != nil {
+			return 0, err
+		}
+
+		d.decoded = bytes.NewReader(decoded)
+	}
+
+	return d.decoded.Read(p)
+}
+
+var (
+	_ io.WriteCloser = &Encoder{}
+	_ io.Reader      = &Decoder{}
+)
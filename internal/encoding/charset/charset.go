@@ -0,0 +1,208 @@
+// Copyright (C) 2022 Mya Pitzeruse
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package charset
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/ianaindex"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// Charset pairs the encodings used when reading and writing a character set. They only differ in how byte order
+// marks are handled. When reading, a leading BOM is always stripped. When writing, a BOM is only emitted when the
+// charset requires one.
+type Charset struct {
+	Name  string
+	Read  encoding.Encoding
+	Write encoding.Encoding
+}
+
+func symmetric(name string, enc encoding.Encoding) Charset {
+	return Charset{Name: name, Read: enc, Write: enc}
+}
+
+var charsets = map[string]Charset{
+	"utf8bom": symmetric("utf8bom", unicode.UTF8BOM),
+	"utf16":   symmetric("utf16", unicode.UTF16(unicode.BigEndian, unicode.UseBOM)),
+	"utf16le": {
+		Name:  "utf16le",
+		Read:  unicode.UTF16(unicode.LittleEndian, unicode.UseBOM),
+		Write: unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM),
+	},
+	"utf16be": {
+		Name:  "utf16be",
+		Read:  unicode.UTF16(unicode.BigEndian, unicode.UseBOM),
+		Write: unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM),
+	},
+	"latin1":      symmetric("latin1", charmap.ISO8859_1),
+	"windows1252": symmetric("windows1252", charmap.Windows1252),
+	"cp1252":      symmetric("cp1252", charmap.Windows1252),
+	"shiftjis":    symmetric("shiftjis", japanese.ShiftJIS),
+	"sjis":        symmetric("sjis", japanese.ShiftJIS),
+	"gbk":         symmetric("gbk", simplifiedchinese.GBK),
+}
+
+// Lookup returns the charset registered under the provided name. Short names (utf16le, latin1, sjis, gbk, ...) are
+// checked first before falling back to IANA registered names (ISO-8859-2, windows-1252, Shift_JIS, ...).
+func Lookup(name string) (Charset, bool) {
+	short := strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(name))
+	if cs, ok := charsets[short]; ok {
+		return cs, true
+	}
+
+	enc, err := ianaindex.IANA.Encoding(name)
+	if err != nil || enc == nil {
+		return Charset{}, false
+	}
+
+	return symmetric(name, enc), true
+}
+
+// InvalidSequenceError reports the byte offset of a sequence that could not be transcoded. When reading, the offset
+// refers to the raw input. When writing, the offset refers to the UTF-8 data being encoded.
+type InvalidSequenceError struct {
+	Charset string
+	Offset  int64
+	Err     error
+}
+
+func (e *InvalidSequenceError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("invalid %s sequence at byte offset %d: %v", e.Charset, e.Offset, e.Err)
+	}
+
+	return fmt.Sprintf("invalid %s sequence at byte offset %d", e.Charset, e.Offset)
+}
+
+func (e *InvalidSequenceError) Unwrap() error {
+	return e.Err
+}
+
+// NewDecoder returns a reader that converts data in the provided charset into UTF-8.
+func NewDecoder(cs Charset, reader io.Reader) io.Reader {
+	return transform.NewReader(reader, &strict{
+		charset:     cs.Name,
+		inner:       cs.Read.NewDecoder(),
+		decoding:    true,
+		replacement: encodedReplacement(cs.Write),
+	})
+}
+
+// NewEncoder returns a writer that converts UTF-8 data into the provided charset. The writer must be closed to
+// flush any buffered data.
+func NewEncoder(cs Charset, writer io.Writer) io.WriteCloser {
+	return transform.NewWriter(writer, &strict{
+		charset: cs.Name,
+		inner:   cs.Write.NewEncoder(),
+	})
+}
+
+var replacement = []byte("\uFFFD")
+
+// encodedReplacement returns how the charset encodes U+FFFD, or nil when it cannot be represented. Two characters are
+// encoded so that a leading byte order mark can be dropped.
+func encodedReplacement(enc encoding.Encoding) []byte {
+	one, err := enc.NewEncoder().Bytes(replacement)
+	if err != nil {
+		return nil
+	}
+
+	two, err := enc.NewEncoder().Bytes(append(append([]byte{}, replacement...), replacement...))
+	if err != nil {
+		return nil
+	}
+
+	return two[len(one):]
+}
+
+// strict wraps a transformer so that invalid sequences fail with their exact byte offset. Encoders in x/text stop at
+// the first character they cannot encode. Decoders replace invalid input with U+FFFD instead, so when decoding, input
+// is fed one character at a time and a replacement character is only accepted when it is what the input encodes.
+type strict struct {
+	charset     string
+	inner       transform.Transformer
+	decoding    bool
+	replacement []byte
+	offset      int64
+}
+
+func (t *strict) Reset() {
+	t.inner.Reset()
+	t.offset = 0
+}
+
+// encodedReplacements counts the U+FFFD characters legitimately encoded in src.
+func (t *strict) encodedReplacements(src []byte) int {
+	if t.replacement == nil {
+		return 0
+	}
+
+	return bytes.Count(src, t.replacement)
+}
+
+func (t *strict) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	defer func() {
+		t.offset += int64(nSrc)
+	}()
+
+	if !t.decoding {
+		nDst, nSrc, err = t.inner.Transform(dst, src, atEOF)
+		if err != nil && err != transform.ErrShortSrc && err != transform.ErrShortDst {
+			err = &InvalidSequenceError{Charset: t.charset, Offset: t.offset + int64(nSrc), Err: err}
+		}
+
+		return nDst, nSrc, err
+	}
+
+	for nSrc < len(src) {
+		var n, m int
+
+		end := nSrc + 1
+		for {
+			n, m, err = t.inner.Transform(dst[nDst:], src[nSrc:end], atEOF && end == len(src))
+			if err != transform.ErrShortSrc || end == len(src) {
+				break
+			}
+
+			end++
+		}
+
+		switch {
+		case bytes.Count(dst[nDst:nDst+n], replacement) > t.encodedReplacements(src[nSrc:nSrc+m]):
+			return nDst, nSrc, &InvalidSequenceError{Charset: t.charset, Offset: t.offset + int64(nSrc)}
+		case err == transform.ErrShortSrc, err == transform.ErrShortDst:
+			return nDst + n, nSrc + m, err
+		case err != nil:
+			return nDst, nSrc, &InvalidSequenceError{Charset: t.charset, Offset: t.offset + int64(nSrc+m), Err: err}
+		case m == 0:
+			return nDst + n, nSrc, transform.ErrShortSrc
+		}
+
+		nDst += n
+		nSrc += m
+	}
+
+	return nDst, nSrc, nil
+}
@@ -0,0 +1,125 @@
+// Copyright (C) 2022 Mya Pitzeruse
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package classic
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// bruteLimit is the number of candidates printed by the brute force modes.
+const bruteLimit = 10
+
+// english contains the relative frequency of letters and spaces in English text.
+var english = map[byte]float64{
+	' ': 0.1828,
+	'a': 0.0653, 'b': 0.0126, 'c': 0.0223, 'd': 0.0328, 'e': 0.1027, 'f': 0.0198, 'g': 0.0162,
+	'h': 0.0498, 'i': 0.0567, 'j': 0.0010, 'k': 0.0056, 'l': 0.0332, 'm': 0.0203, 'n': 0.0571,
+	'o': 0.0616, 'p': 0.0150, 'q': 0.0008, 'r': 0.0499, 's': 0.0532, 't': 0.0752, 'u': 0.0228,
+	'v': 0.0080, 'w': 0.0170, 'x': 0.0014, 'y': 0.0143, 'z': 0.0005,
+}
+
+// Score estimates how much data looks like English text as the average log probability of each byte. Higher scores
+// are more English-like. Punctuation and digits are plausible but rare, and control or non-ASCII bytes are heavily
+// penalized.
+func Score(data []byte) float64 {
+	if len(data) == 0 {
+		return math.Inf(-1)
+	}
+
+	total := 0.0
+
+	for _, b := range data {
+		if b >= 'A' && b <= 'Z' {
+			b += 'a' - 'A'
+		}
+
+		p, ok := english[b]
+
+		switch {
+		case ok:
+		case b >= '0' && b <= '9':
+			p = 0.002
+		case b > ' ' && b <= '~', b == '\n', b == '\t':
+			p = 0.001
+		default:
+			p = 1e-6
+		}
+
+		total += math.Log(p)
+	}
+
+	return total / float64(len(data))
+}
+
+// Candidate is a possible plaintext produced by a brute force search.
+type Candidate struct {
+	Key   string
+	Score float64
+	Text  []byte
+}
+
+func rank(candidates []Candidate) []byte {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+
+	if len(candidates) > bruteLimit {
+		candidates = candidates[:bruteLimit]
+	}
+
+	buf := &bytes.Buffer{}
+	for _, candidate := range candidates {
+		_, _ = fmt.Fprintf(buf, "%s\t%.3f\t%s\n", candidate.Key, candidate.Score, strconv.Quote(string(candidate.Text)))
+	}
+
+	return buf.Bytes()
+}
+
+// BruteCaesar tries every Caesar shift and returns the most English-like candidates, one per line.
+func BruteCaesar(data []byte) ([]byte, error) {
+	candidates := make([]Candidate, 0, 25)
+
+	for shift := 1; shift < 26; shift++ {
+		text := Caesar(data, -shift)
+		candidates = append(candidates, Candidate{
+			Key:   "shift=" + strconv.Itoa(shift),
+			Score: Score(text),
+			Text:  text,
+		})
+	}
+
+	return rank(candidates), nil
+}
+
+// BruteXOR tries every single byte XOR key and returns the most English-like candidates, one per line.
+func BruteXOR(data []byte) ([]byte, error) {
+	candidates := make([]Candidate, 0, 255)
+
+	for key := 1; key < 256; key++ {
+		text := XOR(data, []byte{byte(key)})
+		candidates = append(candidates, Candidate{
+			Key:   fmt.Sprintf("key=0x%02x", key),
+			Score: Score(text),
+			Text:  text,
+		})
+	}
+
+	return rank(candidates), nil
+}
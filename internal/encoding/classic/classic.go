@@ -0,0 +1,163 @@
+// Copyright (C) 2022 Mya Pitzeruse
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package classic implements classical ciphers and text transforms. None of these provide any security; they exist
+// for puzzles and for recognizing data that has been obscured with them.
+package classic
+
+import (
+	"fmt"
+
+	"go.pitz.tech/em/internal/encoding/buffered"
+)
+
+func isLetter(b byte) bool {
+	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+
+func shiftLetter(b byte, shift int) byte {
+	switch {
+	case b >= 'a' && b <= 'z':
+		return 'a' + byte((int(b-'a')+shift%26+26)%26)
+	case b >= 'A' && b <= 'Z':
+		return 'A' + byte((int(b-'A')+shift%26+26)%26)
+	}
+
+	return b
+}
+
+// Caesar shifts each ASCII letter forward by shift positions, preserving case. Other bytes are left as is.
+func Caesar(data []byte, shift int) []byte {
+	out := make([]byte, len(data))
+	for i, b := range data {
+		out[i] = shiftLetter(b, shift)
+	}
+
+	return out
+}
+
+// ROT13 is the Caesar cipher with a shift of 13, making it its own inverse.
+func ROT13(data []byte) ([]byte, error) {
+	return Caesar(data, 13), nil
+}
+
+// ROT47 rotates the printable ASCII range (! through ~) by 47 positions, making it its own inverse.
+func ROT47(data []byte) ([]byte, error) {
+	out := make([]byte, len(data))
+	for i, b := range data {
+		if b >= '!' && b <= '~' {
+			b = '!' + (b-'!'+47)%94
+		}
+
+		out[i] = b
+	}
+
+	return out, nil
+}
+
+// Atbash mirrors the alphabet (a becomes z, b becomes y, ...), making it its own inverse.
+func Atbash(data []byte) ([]byte, error) {
+	out := make([]byte, len(data))
+	for i, b := range data {
+		switch {
+		case b >= 'a' && b <= 'z':
+			b = 'z' - (b - 'a')
+		case b >= 'A' && b <= 'Z':
+			b = 'Z' - (b - 'A')
+		}
+
+		out[i] = b
+	}
+
+	return out, nil
+}
+
+// EncodeCaesar returns a function that shifts letters forward by shift positions.
+func EncodeCaesar(shift int) buffered.Func {
+	return func(data []byte) ([]byte, error) {
+		return Caesar(data, shift), nil
+	}
+}
+
+// DecodeCaesar returns a function that shifts letters back by shift positions.
+func DecodeCaesar(shift int) buffered.Func {
+	return EncodeCaesar(-shift)
+}
+
+func vigenere(key string, direction int) buffered.Func {
+	return func(data []byte) ([]byte, error) {
+		if key == "" {
+			return nil, fmt.Errorf("a key is required for vigenere")
+		}
+
+		shifts := make([]int, len(key))
+		for i := 0; i < len(key); i++ {
+			switch k := key[i]; {
+			case k >= 'a' && k <= 'z':
+				shifts[i] = int(k - 'a')
+			case k >= 'A' && k <= 'Z':
+				shifts[i] = int(k - 'A')
+			default:
+				return nil, fmt.Errorf("vigenere keys may only contain letters")
+			}
+		}
+
+		out := make([]byte, len(data))
+		j := 0
+
+		for i, b := range data {
+			out[i] = b
+
+			// the key only advances on letters so punctuation and spacing are carried through untouched
+			if isLetter(b) {
+				out[i] = shiftLetter(b, direction*shifts[j%len(shifts)])
+				j++
+			}
+		}
+
+		return out, nil
+	}
+}
+
+// EncodeVigenere returns a function that shifts each letter by the corresponding letter of key.
+func EncodeVigenere(key string) buffered.Func {
+	return vigenere(key, 1)
+}
+
+// DecodeVigenere returns a function that reverses EncodeVigenere.
+func DecodeVigenere(key string) buffered.Func {
+	return vigenere(key, -1)
+}
+
+// XOR combines data with key, repeating the key as needed. Applying it twice with the same key restores the input.
+func XOR(data, key []byte) []byte {
+	out := make([]byte, len(data))
+	for i, b := range data {
+		out[i] = b ^ key[i%len(key)]
+	}
+
+	return out
+}
+
+// RepeatingXOR returns a function that combines data with a repeating key.
+func RepeatingXOR(key string) buffered.Func {
+	return func(data []byte) ([]byte, error) {
+		if key == "" {
+			return nil, fmt.Errorf("a key is required for xor")
+		}
+
+		return XOR(data, []byte(key)), nil
+	}
+}
@@ -0,0 +1,284 @@
+// Copyright (C) 2022 Mya Pitzeruse
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package encoding
+
+import (
+	"bytes"
+	"context"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime/quotedprintable"
+
+	"go.uber.org/zap"
+
+	"go.pitz.tech/em/internal/encoding/armor"
+	"go.pitz.tech/em/internal/encoding/base58"
+	"go.pitz.tech/em/internal/encoding/buffered"
+	"go.pitz.tech/em/internal/encoding/charset"
+	"go.pitz.tech/em/internal/encoding/classic"
+	"go.pitz.tech/em/internal/encoding/detect"
+	"go.pitz.tech/em/internal/encoding/escape"
+	"go.pitz.tech/em/internal/encoding/hexdump"
+	"go.pitz.tech/em/internal/encoding/literal"
+	"go.pitz.tech/em/internal/encoding/morse"
+	"go.pitz.tech/em/internal/encoding/nato"
+	"go.pitz.tech/em/internal/encoding/padding"
+	"go.pitz.tech/em/internal/encoding/phone"
+	"go.pitz.tech/em/internal/encoding/qr"
+	"go.pitz.tech/em/internal/encoding/radix"
+	"go.pitz.tech/em/internal/encoding/schemaless"
+	"go.pitz.tech/em/internal/encoding/wrap"
+
+	"go.pitz.tech/lib/logger"
+)
+
+// NewDecoder returns a reader that decodes data from reader using the configured input encoding.
+func NewDecoder(ctx context.Context, cfg EncodeConfig, reader io.Reader) (io.Reader, error) {
+	if cfg.Brute && cfg.In != "caesar" && cfg.In != "xor" {
+		return nil, fmt.Errorf("brute force is only supported for caesar and xor input")
+	}
+
+	switch cfg.In {
+	case "base64", "b64", "base64raw", "b64raw":
+		return base64.NewDecoder(base64.RawStdEncoding, padding.NewReader(reader)), nil
+	case "base64url", "b64url", "base64urlraw", "b64urlraw":
+		return base64.NewDecoder(base64.RawURLEncoding, padding.NewReader(reader)), nil
+	case "base32", "b32", "base32raw", "b32raw":
+		return base32.NewDecoder(base32.StdEncoding.WithPadding(base32.NoPadding), padding.NewReader(reader)), nil
+	case "base32hex", "b32hex", "base32hexraw", "b32hexraw":
+		return base32.NewDecoder(base32.HexEncoding.WithPadding(base32.NoPadding), padding.NewReader(reader)), nil
+	case "base2", "binary", "bin":
+		return buffered.NewDecoder(reader, radix.DecodeBinary), nil
+	case "octal", "oct":
+		return buffered.NewDecoder(reader, radix.DecodeOctal), nil
+	case "base58", "b58":
+		return base58.NewDecoder(reader), nil
+	case "hex":
+		return hex.NewDecoder(reader), nil
+	case "hexdump":
+		return hexdump.NewDecoder(reader), nil
+	case "pem":
+		return buffered.NewDecoder(reader, armor.Decode(cfg.PEMType, cfg.PEMIndex)), nil
+	case "protobuf-raw", "protobuf", "proto":
+		return buffered.NewDecoder(reader, schemaless.DecodeProtobuf), nil
+	case "asn1", "der":
+		return buffered.NewDecoder(reader, schemaless.DecodeASN1), nil
+	case "url", "query":
+		return buffered.NewDecoder(reader, escape.QueryUnescape), nil
+	case "urlpath", "path":
+		return buffered.NewDecoder(reader, escape.PathUnescape), nil
+	case "quoted-printable", "qp":
+		return quotedprintable.NewReader(reader), nil
+	case "html":
+		return buffered.NewDecoder(reader, escape.HTMLUnescape), nil
+	case "json":
+		return buffered.NewDecoder(reader, escape.JSONUnescape), nil
+	case "unicode-escape", "uesc", "hex-escape", "xesc":
+		return buffered.NewDecoder(reader, escape.Unescape), nil
+	case "rot13":
+		return buffered.NewDecoder(reader, classic.ROT13), nil
+	case "rot47":
+		return buffered.NewDecoder(reader, classic.ROT47), nil
+	case "atbash":
+		return buffered.NewDecoder(reader, classic.Atbash), nil
+	case "caesar":
+		if cfg.Brute {
+			return buffered.NewDecoder(reader, classic.BruteCaesar), nil
+		}
+
+		return buffered.NewDecoder(reader, classic.DecodeCaesar(cfg.Shift)), nil
+	case "vigenere":
+		return buffered.NewDecoder(reader, classic.DecodeVigenere(cfg.Key)), nil
+	case "xor":
+		if cfg.Brute {
+			return buffered.NewDecoder(reader, classic.BruteXOR), nil
+		}
+
+		return buffered.NewDecoder(reader, classic.RepeatingXOR(cfg.Key)), nil
+	case "morse":
+		return buffered.NewDecoder(reader, morse.Decode), nil
+	case "nato":
+		return buffered.NewDecoder(reader, nato.Decode), nil
+	case "auto":
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, err
+		}
+
+		result := detect.Detect(data)
+		logger.Extract(ctx).Debug("detected input encoding",
+			zap.String("encoding", result.Encoding),
+			zap.Float64("score", result.Score),
+		)
+
+		return bytes.NewReader(result.Decoded), nil
+	case "ascii", "utf8":
+		return reader, nil
+	default:
+		cs, ok := charset.Lookup(cfg.In)
+		if !ok {
+			return nil, fmt.Errorf("unrecognized input encoding: %s", cfg.In)
+		}
+
+		return charset.NewDecoder(cs, reader), nil
+	}
+}
+
+// NewEncoder returns a writer that encodes data written to it using the configured output encoding. The returned
+// writer must be closed to flush any buffered data.
+func NewEncoder(cfg EncodeConfig, writer io.Writer) (io.WriteCloser, error) {
+	var wrapped *wrap.Writer
+	var sink io.Writer = writer
+	if cfg.Wrap > 0 {
+		wrapped = wrap.NewWriter(writer, cfg.Wrap)
+		sink = wrapped
+	}
+
+	var encoder io.Writer
+	switch cfg.Out {
+	case "base64", "b64":
+		encoder = base64.NewEncoder(base64.StdEncoding, sink)
+	case "base64url", "b64url":
+		encoder = base64.NewEncoder(base64.URLEncoding, sink)
+	case "base32", "b32":
+		encoder = base32.NewEncoder(base32.StdEncoding, sink)
+	case "base32hex", "b32hex":
+		encoder = base32.NewEncoder(base32.HexEncoding, sink)
+	case "base64raw", "b64raw":
+		encoder = base64.NewEncoder(base64.RawStdEncoding, sink)
+	case "base64urlraw", "b64urlraw":
+		encoder = base64.NewEncoder(base64.RawURLEncoding, sink)
+	case "base32raw", "b32raw":
+		encoder = base32.NewEncoder(base32.StdEncoding.WithPadding(base32.NoPadding), sink)
+	case "base32hexraw", "b32hexraw":
+		encoder = base32.NewEncoder(base32.HexEncoding.WithPadding(base32.NoPadding), sink)
+	case "base2", "binary", "bin":
+		encoder = buffered.NewEncoder(writer, radix.EncodeBinary)
+	case "octal", "oct":
+		encoder = buffered.NewEncoder(writer, radix.EncodeOctal)
+	case "base58", "b58":
+		encoder = base58.NewEncoder(writer)
+	case "hex":
+		encoder = hex.NewEncoder(sink)
+	case "hexdump":
+		encoder = hexdump.NewEncoder(writer, cfg.Width, cfg.Group)
+	case "pem":
+		encoder = buffered.NewEncoder(writer, armor.Encode(cfg.PEMType))
+	case "go":
+		encoder = buffered.NewEncoder(writer, literal.Encode(literal.Go, cfg.Name, cfg.Width))
+	case "c":
+		encoder = buffered.NewEncoder(writer, literal.Encode(literal.C, cfg.Name, cfg.Width))
+	case "rust":
+		encoder = buffered.NewEncoder(writer, literal.Encode(literal.Rust, cfg.Name, cfg.Width))
+	case "url", "query":
+		encoder = buffered.NewEncoder(writer, escape.QueryEscape)
+	case "urlpath", "path":
+		encoder = buffered.NewEncoder(writer, escape.PathEscape)
+	case "quoted-printable", "qp":
+		encoder = quotedprintable.NewWriter(writer)
+	case "html":
+		encoder = buffered.NewEncoder(writer, escape.HTMLEscape)
+	case "json":
+		encoder = buffered.NewEncoder(writer, escape.JSONEscape)
+	case "unicode-escape", "uesc":
+		encoder = buffered.NewEncoder(writer, escape.UnicodeEscape)
+	case "hex-escape", "xesc":
+		encoder = buffered.NewEncoder(writer, escape.HexEscape)
+	case "rot13":
+		encoder = buffered.NewEncoder(writer, classic.ROT13)
+	case "rot47":
+		encoder = buffered.NewEncoder(writer, classic.ROT47)
+	case "atbash":
+		encoder = buffered.NewEncoder(writer, classic.Atbash)
+	case "caesar":
+		encoder = buffered.NewEncoder(writer, classic.EncodeCaesar(cfg.Shift))
+	case "vigenere":
+		encoder = buffered.NewEncoder(writer, classic.EncodeVigenere(cfg.Key))
+	case "xor":
+		encoder = buffered.NewEncoder(writer, classic.RepeatingXOR(cfg.Key))
+	case "phone":
+		encoder = phone.NewEncoder(writer)
+	case "morse":
+		encoder = buffered.NewEncoder(writer, morse.Encode)
+	case "nato":
+		encoder = buffered.NewEncoder(writer, nato.Encode)
+	case "qr":
+		encoder = buffered.NewEncoder(writer, qr.Encode(qr.Options{
+			Level:  cfg.QRLevel,
+			File:   cfg.QRFile,
+			Invert: cfg.QRInvert,
+		}))
+	case "ascii", "utf8":
+		encoder = writer
+	default:
+		cs, ok := charset.Lookup(cfg.Out)
+		if !ok {
+			return nil, fmt.Errorf("unrecognized output encoding: %s", cfg.Out)
+		}
+
+		encoder = charset.NewEncoder(cs, writer)
+	}
+
+	chain := &closeChain{Writer: encoder}
+	if closer, ok := encoder.(io.Closer); ok {
+		chain.closers = append(chain.closers, closer)
+	}
+
+	if wrapped != nil {
+		chain.closers = append(chain.closers, wrapped)
+	}
+
+	return chain, nil
+}
+
+// closeChain closes each of the closers in order, returning the first error encountered.
+type closeChain struct {
+	io.Writer
+	closers []io.Closer
+}
+
+func (c *closeChain) Close() (err error) {
+	for _, closer := range c.closers {
+		if closeErr := closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+
+	return err
+}
+
+// EncodeToString encodes data using the configured output encoding.
+func EncodeToString(cfg EncodeConfig, data []byte) (string, error) {
+	buf := &bytes.Buffer{}
+
+	encoder, err := NewEncoder(cfg, buf)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err = encoder.Write(data); err != nil {
+		return "", err
+	}
+
+	if err = encoder.Close(); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
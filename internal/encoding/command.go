@@ -17,91 +17,104 @@ package encoding
 
 import (
 	"bufio"
-	"encoding/base32"
-	"encoding/base64"
-	"encoding/hex"
+	"context"
 	"io"
 	"os"
 	"strings"
 
 	"github.com/urfave/cli/v2"
-	"go.pitz.tech/em/internal/encoding/phone"
 
 	"go.pitz.tech/lib/flagset"
 )
 
 type EncodeConfig struct {
-	In  string `json:"in"  alias:"i" usage:"the input encoding"  default:"ascii"`
+	In  string `json:"in"  alias:"i" usage:"the input encoding, or auto to detect it"  default:"ascii"`
 	Out string `json:"out" alias:"o" usage:"the output encoding" default:"ascii"`
+
+	Width int `json:"width" usage:"the number of bytes written per line by hexdump (default 16) and source literal (default 12) output"`
+	Group int `json:"group" usage:"the number of bytes grouped together by hexdump output" default:"8"`
+	Wrap  int `json:"wrap"  usage:"wrap base64, base32, and hex output after the given number of characters"`
+
+	PEMType  string `json:"pem_type"  usage:"the pem block type to write, or to select when reading"`
+	PEMIndex int    `json:"pem_index" usage:"the index of the pem block to read, after filtering by type"`
+
+	Name string `json:"name" usage:"the variable name used by source literal output (go, c, rust)" default:"data"`
+
+	Shift int    `json:"shift" usage:"the number of positions letters are shifted by caesar" default:"3"`
+	Key   string `json:"key"   usage:"the key used by vigenere and xor"`
+	Brute bool   `json:"brute" usage:"try every caesar shift or single byte xor key and print the most english-like results"`
+
+	File        *cli.StringSlice `json:"file"        alias:"f" usage:"transcode the files matching the glob instead of stdin, may be repeated"`
+	OutputDir   string           `json:"output_dir"  usage:"the directory transcoded files are written to (defaults to alongside the input)"`
+	Suffix      string           `json:"suffix"      usage:"the suffix appended to the name of each transcoded file"`
+	Concurrency int              `json:"concurrency" usage:"the maximum number of files transcoded at once" default:"8"`
+
+	QRLevel  string `json:"qr_level"  usage:"the error correction level used by qr output (low, medium, quartile, high)" default:"medium"`
+	QRFile   string `json:"qr_file"   usage:"write qr output to a png or svg image instead of the terminal"`
+	QRInvert bool   `json:"qr_invert" usage:"swap light and dark modules of qr output for light terminal backgrounds"`
+}
+
+// transcode decodes everything read from reader using the input encoding and writes it to writer using the output
+// encoding.
+func transcode(ctx context.Context, cfg EncodeConfig, reader io.Reader, writer io.Writer) (err error) {
+	decoder, err := NewDecoder(ctx, cfg, reader)
+	if err != nil {
+		return err
+	}
+
+	encoder, err := NewEncoder(cfg, writer)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if readCloser, rcOK := decoder.(io.Closer); rcOK {
+			_ = readCloser.Close()
+		}
+
+		if closeErr := encoder.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	_, err = io.Copy(encoder, decoder)
+	switch {
+	case err == io.EOF:
+	case err != nil:
+		return err
+	}
+
+	return nil
 }
 
 var (
-	encodeConfig = &EncodeConfig{}
+	encodeConfig = &EncodeConfig{
+		File: cli.NewStringSlice(),
+	}
 
 	Command = &cli.Command{
-		Name:      "encode",
-		Usage:     "Read and write different encodings.",
-		UsageText: "em encode [message]",
-		Flags:     flagset.ExtractPrefix("em", encodeConfig),
-		Aliases:   []string{"enc"},
+		Name:  "encode",
+		Usage: "Read and write different encodings.",
+		UsageText: strings.Join([]string{
+			"em encode [message]",
+			"em encode --in base64 --out hex --file 'fixtures/*.b64' --output-dir out --suffix .hex",
+		}, "\n"),
+		Flags:   flagset.ExtractPrefix("em", encodeConfig),
+		Aliases: []string{"enc"},
 		Action: func(ctx *cli.Context) error {
+			if len(encodeConfig.File.Value()) > 0 {
+				return batch(ctx, *encodeConfig)
+			}
+
 			writer := bufio.NewWriter(ctx.App.Writer)
+			defer writer.Flush()
 
 			var reader io.Reader = bufio.NewReader(os.Stdin)
 			if ctx.NArg() > 0 {
 				reader = strings.NewReader(ctx.Args().Get(0))
 			}
 
-			decoder := reader
-			switch encodeConfig.In {
-			case "base64", "b64":
-				decoder = base64.NewDecoder(base64.StdEncoding, reader)
-			case "base64url", "b64url":
-				decoder = base64.NewDecoder(base64.URLEncoding, reader)
-			case "base32", "b32":
-				decoder = base32.NewDecoder(base32.StdEncoding, reader)
-			case "base32hex", "b32hex":
-				decoder = base32.NewDecoder(base32.HexEncoding, reader)
-			case "hex":
-				decoder = hex.NewDecoder(reader)
-			}
-
-			var encoder io.Writer = writer
-			switch encodeConfig.Out {
-			case "base64", "b64":
-				encoder = base64.NewEncoder(base64.StdEncoding, writer)
-			case "base64url", "b64url":
-				encoder = base64.NewEncoder(base64.URLEncoding, writer)
-			case "base32", "b32":
-				encoder = base32.NewEncoder(base32.StdEncoding, writer)
-			case "base32hex", "b32hex":
-				encoder = base32.NewEncoder(base32.HexEncoding, writer)
-			case "hex":
-				encoder = hex.NewEncoder(writer)
-			case "phone":
-				encoder = phone.NewEncoder(writer)
-			}
-
-			defer func() {
-				defer writer.Flush()
-
-				if readCloser, rcOK := decoder.(io.Closer); rcOK {
-					_ = readCloser.Close()
-				}
-
-				if writeCloser, wcOK := encoder.(io.Closer); wcOK {
-					_ = writeCloser.Close()
-				}
-			}()
-
-			_, err := io.Copy(encoder, decoder)
-			switch {
-			case err == io.EOF:
-			case err != nil:
-				return err
-			}
-
-			return nil
+			return transcode(ctx.Context, *encodeConfig, reader, writer)
 		},
 		HideHelpCommand: true,
 	}
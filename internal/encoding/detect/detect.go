@@ -0,0 +1,233 @@
+// Copyright (C) 2022 Mya Pitzeruse
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package detect
+
+import (
+	"bytes"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"go.pitz.tech/em/internal/encoding/base58"
+)
+
+// ASCII is reported when the input does not look like any of the known encodings.
+const ASCII = "ascii"
+
+// candidate describes an encoding that can be detected. The alphabet excludes padding. Encodings that group their
+// output into blocks (base64 and base32) set block to the number of characters per block and may be padded with '='.
+// The prior is the evidence an encoding starts with, relative to the others, based on how often it is encountered.
+type candidate struct {
+	name     string
+	alphabet string
+	block    int
+	prior    float64
+	decode   func(string) ([]byte, error)
+}
+
+// either attempts to decode the input using each of the provided decoders, returning the first successful result.
+func either(decoders ...func(string) ([]byte, error)) func(string) ([]byte, error) {
+	return func(s string) (decoded []byte, err error) {
+		for _, decode := range decoders {
+			if decoded, err = decode(s); err == nil {
+				return decoded, nil
+			}
+		}
+
+		return nil, err
+	}
+}
+
+const (
+	digits = "0123456789"
+	upper  = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	lower  = "abcdefghijklmnopqrstuvwxyz"
+)
+
+var candidates = []candidate{
+	{
+		name:     "hex",
+		alphabet: digits + "abcdefABCDEF",
+		decode:   hex.DecodeString,
+	},
+	{
+		name:     "base32",
+		alphabet: upper + "234567",
+		block:    8,
+		decode: either(
+			base32.StdEncoding.DecodeString,
+			base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString,
+		),
+	},
+	{
+		name:     "base58",
+		alphabet: base58.Alphabet,
+		prior:    -3,
+		decode: func(s string) ([]byte, error) {
+			return base58.Decode([]byte(s))
+		},
+	},
+	{
+		name:     "base64",
+		alphabet: upper + lower + digits + "+/",
+		block:    4,
+		decode:   either(base64.StdEncoding.DecodeString, base64.RawStdEncoding.DecodeString),
+	},
+	{
+		name:     "base64url",
+		alphabet: upper + lower + digits + "-_",
+		block:    4,
+		decode:   either(base64.URLEncoding.DecodeString, base64.RawURLEncoding.DecodeString),
+	},
+}
+
+// textual is the number of bits of evidence each printable byte provides. Random binary data is rarely printable,
+// so decoding into text is a strong signal that the right encoding was chosen.
+var textual = math.Log2(256.0 / 95.0)
+
+const (
+	// padded is the evidence provided by a complete final block with valid padding, which plain text rarely ends in.
+	padded = 6.0
+	// aligned is the evidence provided by unpadded input that fills a whole number of blocks.
+	aligned = 2.0
+	// spacing is the evidence each run of spaces or tabs within the input provides for plain text. Encoders wrap
+	// their output using newlines, but never put spaces between characters.
+	spacing = 8.0
+)
+
+// specificity is the number of bits of evidence each character provides when it falls within an alphabet, compared to
+// arbitrary printable text. Inputs that stay within a small alphabet are unlikely to be anything else.
+func specificity(alphabet int) float64 {
+	return math.Log2(95 / float64(alphabet))
+}
+
+// plain scores the evidence that the input is ordinary text. Text is made of words separated by spaces, and each word
+// is usually written in lowercase, uppercase, or capitalized.
+func plain(data []byte) float64 {
+	score := 0.0
+
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		words := bytes.Fields(line)
+		if len(words) > 1 {
+			score += float64(len(words)-1) * spacing
+		}
+
+		for _, word := range words {
+			if within(string(word), lower) || within(string(word), upper) ||
+				(within(string(word[:1]), upper) && within(string(word[1:]), lower)) {
+				score += float64(len(word)) * specificity(len(lower))
+			}
+		}
+	}
+
+	return score
+}
+
+// framing scores how well the input fits the block structure of the candidate. The decoders have already rejected
+// misplaced padding, so any trailing '=' is known to be valid.
+func (c candidate) framing(s string) float64 {
+	switch {
+	case c.block == 0 || len(s)%c.block != 0:
+		return 0
+	case strings.HasSuffix(s, "="):
+		return padded
+	default:
+		return aligned
+	}
+}
+
+// Result contains the outcome of detecting the encoding of a given input.
+type Result struct {
+	Encoding string
+	Decoded  []byte
+	Score    float64
+}
+
+// Detect scores data against each known alphabet and returns the best match along with the decoded data. If no
+// encoding scores better than treating the input as plain text, the input is returned as is and ASCII is reported.
+func Detect(data []byte) Result {
+	stripped := string(bytes.Join(bytes.Fields(data), nil))
+
+	best := Result{
+		Encoding: ASCII,
+		Decoded:  data,
+		Score:    plain(data),
+	}
+
+	if len(stripped) == 0 {
+		return best
+	}
+
+	for _, c := range candidates {
+		alphabet := c.alphabet
+		if c.block > 0 {
+			alphabet += "="
+		}
+
+		if !within(stripped, alphabet) {
+			continue
+		}
+
+		score := c.prior + float64(len(stripped))*specificity(len(c.alphabet))
+
+		decoded, err := c.decode(stripped)
+		if err != nil {
+			continue
+		}
+
+		score += c.framing(stripped) + printable(decoded)*textual
+
+		if score > best.Score {
+			best = Result{
+				Encoding: c.name,
+				Decoded:  decoded,
+				Score:    score,
+			}
+		}
+	}
+
+	return best
+}
+
+func within(s, alphabet string) bool {
+	for i := 0; i < len(s); i++ {
+		if strings.IndexByte(alphabet, s[i]) < 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// printable returns the number of bytes in data that are part of printable characters.
+func printable(data []byte) float64 {
+	count := 0
+
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		if r != utf8.RuneError && (unicode.IsPrint(r) || unicode.IsSpace(r)) {
+			count += size
+		}
+
+		data = data[size:]
+	}
+
+	return float64(count)
+}
@@ -0,0 +1,87 @@
+// Copyright (C) 2022 Mya Pitzeruse
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package detect
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"math/rand"
+	"testing"
+
+	"go.pitz.tech/em/internal/encoding/base58"
+)
+
+func TestDetect(t *testing.T) {
+	digest := sha256.Sum256([]byte("x"))
+
+	blob := make([]byte, 300)
+	rand.New(rand.NewSource(1)).Read(blob)
+
+	testCases := []struct {
+		name     string
+		input    string
+		encoding string
+		decoded  []byte
+	}{
+		{"binary base64", base64.StdEncoding.EncodeToString(digest[:]), "base64", digest[:]},
+		{"binary base64url raw", base64.RawURLEncoding.EncodeToString(digest[:]), "base64url", digest[:]},
+		{"binary blob", base64.StdEncoding.EncodeToString(blob), "base64", blob},
+		{"wrapped blob", wrap(base64.StdEncoding.EncodeToString(blob), 76), "base64", blob},
+		{"padded binary", "3q2+7w==", "base64", []byte{0xde, 0xad, 0xbe, 0xef}},
+		{"unpadded binary", "AAECAwQF", "base64", []byte{0, 1, 2, 3, 4, 5}},
+		{"padded text with newline", "aGVsbG8=\n", "base64", []byte("hello")},
+		{"double padded text with newline", "dGVzdA==\n", "base64", []byte("test")},
+		{"padded password with newline", "cGFzc3dvcmQ=\n", "base64", []byte("password")},
+		{"unpadded text", "aGVsbG8gd29ybGQ", "base64", []byte("hello world")},
+		{"hex text", "68656c6c6f\n", "hex", []byte("hello")},
+		{"hex binary", hex.EncodeToString(digest[:]), "hex", digest[:]},
+		{"hex digits", "2345", "hex", []byte{0x23, 0x45}},
+		{"base32 text", "NBSWY3DP\n", "base32", []byte("hello")},
+		{"base32 binary", base32.StdEncoding.EncodeToString(digest[:]), "base32", digest[:]},
+		{"base58 binary", string(base58.Encode(digest[:])), "base58", digest[:]},
+		{"word", "test\n", ASCII, []byte("test\n")},
+		{"capitalized words", "Hello World\n", ASCII, []byte("Hello World\n")},
+		{"sentence", "the quick brown fox jumps over the lazy dog", ASCII, []byte("the quick brown fox jumps over the lazy dog")},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			result := Detect([]byte(testCase.input))
+
+			if result.Encoding != testCase.encoding {
+				t.Fatalf("expected %s, got %s (score %.1f)", testCase.encoding, result.Encoding, result.Score)
+			}
+
+			if !bytes.Equal(result.Decoded, testCase.decoded) {
+				t.Fatalf("expected %x, got %x", testCase.decoded, result.Decoded)
+			}
+		})
+	}
+}
+
+func wrap(s string, width int) string {
+	buf := &bytes.Buffer{}
+	for len(s) > width {
+		buf.WriteString(s[:width] + "\n")
+		s = s[width:]
+	}
+
+	buf.WriteString(s + "\n")
+
+	return buf.String()
+}
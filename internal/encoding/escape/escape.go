@@ -0,0 +1,208 @@
+// Copyright (C) 2022 Mya Pitzeruse
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package escape
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// QueryEscape percent-encodes data for use within a URL query component.
+func QueryEscape(data []byte) ([]byte, error) {
+	return []byte(url.QueryEscape(string(data))), nil
+}
+
+// QueryUnescape decodes percent-encoded data from a URL query component, treating '+' as a space.
+func QueryUnescape(data []byte) ([]byte, error) {
+	unescaped, err := url.QueryUnescape(string(data))
+	return []byte(unescaped), err
+}
+
+// PathEscape percent-encodes data for use within a URL path segment.
+func PathEscape(data []byte) ([]byte, error) {
+	return []byte(url.PathEscape(string(data))), nil
+}
+
+// PathUnescape decodes percent-encoded data from a URL path segment.
+func PathUnescape(data []byte) ([]byte, error) {
+	unescaped, err := url.PathUnescape(string(data))
+	return []byte(unescaped), err
+}
+
+// HTMLEscape replaces HTML special characters with their entities.
+func HTMLEscape(data []byte) ([]byte, error) {
+	return []byte(html.EscapeString(string(data))), nil
+}
+
+// HTMLUnescape replaces named and numeric HTML entities with the characters they represent.
+func HTMLUnescape(data []byte) ([]byte, error) {
+	return []byte(html.UnescapeString(string(data))), nil
+}
+
+// JSONEscape renders data as a quoted JSON string.
+func JSONEscape(data []byte) ([]byte, error) {
+	return json.Marshal(string(data))
+}
+
+// JSONUnescape parses a JSON string. The surrounding quotes are optional.
+func JSONUnescape(data []byte) ([]byte, error) {
+	trimmed := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(trimmed, `"`) {
+		trimmed = `"` + trimmed + `"`
+	}
+
+	var unescaped string
+	err := json.Unmarshal([]byte(trimmed), &unescaped)
+
+	return []byte(unescaped), err
+}
+
+// UnicodeEscape replaces every non-printable or non-ASCII character with a \uXXXX escape. Characters outside the
+// basic multilingual plane are written as surrogate pairs, and bytes that are not valid UTF-8 are written as \xNN.
+func UnicodeEscape(data []byte) ([]byte, error) {
+	escaped := &strings.Builder{}
+
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+
+		switch {
+		case r == utf8.RuneError && size == 1:
+			_, _ = fmt.Fprintf(escaped, `\x%02x`, data[0])
+		case r == '\\':
+			escaped.WriteString(`\\`)
+		case r >= 0x20 && r <= 0x7e:
+			escaped.WriteRune(r)
+		case r > 0xffff:
+			r1, r2 := utf16.EncodeRune(r)
+			_, _ = fmt.Fprintf(escaped, `\u%04x\u%04x`, r1, r2)
+		default:
+			_, _ = fmt.Fprintf(escaped, `\u%04x`, r)
+		}
+
+		data = data[size:]
+	}
+
+	return []byte(escaped.String()), nil
+}
+
+// HexEscape replaces every byte that is not printable ASCII with a \xNN escape.
+func HexEscape(data []byte) ([]byte, error) {
+	escaped := &strings.Builder{}
+
+	for _, b := range data {
+		switch {
+		case b == '\\':
+			escaped.WriteString(`\\`)
+		case b >= 0x20 && b <= 0x7e:
+			escaped.WriteByte(b)
+		default:
+			_, _ = fmt.Fprintf(escaped, `\x%02x`, b)
+		}
+	}
+
+	return []byte(escaped.String()), nil
+}
+
+var simple = map[byte]byte{
+	'0':  0,
+	'a':  '\a',
+	'b':  '\b',
+	'f':  '\f',
+	'n':  '\n',
+	'r':  '\r',
+	't':  '\t',
+	'v':  '\v',
+	'\\': '\\',
+	'/':  '/',
+	'"':  '"',
+	'\'': '\'',
+}
+
+// Unescape interprets backslash escapes as written by UnicodeEscape and HexEscape, along with the common single
+// character escapes (\n, \t, ...) and \UXXXXXXXX. Surrogate pairs are combined into a single character.
+func Unescape(data []byte) ([]byte, error) {
+	unescaped := make([]byte, 0, len(data))
+
+	for i := 0; i < len(data); i++ {
+		if data[i] != '\\' {
+			unescaped = append(unescaped, data[i])
+			continue
+		}
+
+		if i+1 == len(data) {
+			return nil, fmt.Errorf("truncated escape at byte offset %d", i)
+		}
+
+		if b, ok := simple[data[i+1]]; ok {
+			unescaped = append(unescaped, b)
+			i++
+
+			continue
+		}
+
+		var digits int
+
+		switch data[i+1] {
+		case 'x':
+			digits = 2
+		case 'u':
+			digits = 4
+		case 'U':
+			digits = 8
+		default:
+			return nil, fmt.Errorf("unrecognized escape %q at byte offset %d", data[i:i+2], i)
+		}
+
+		if i+2+digits > len(data) {
+			return nil, fmt.Errorf("truncated escape at byte offset %d", i)
+		}
+
+		value, err := strconv.ParseUint(string(data[i+2:i+2+digits]), 16, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid escape %q at byte offset %d", data[i:i+2+digits], i)
+		}
+
+		start := i
+		i += 1 + digits
+
+		switch r := rune(value); {
+		case digits == 2:
+			unescaped = append(unescaped, byte(value))
+		case utf16.IsSurrogate(r):
+			if i+6 >= len(data) || data[i+1] != '\\' || data[i+2] != 'u' {
+				return nil, fmt.Errorf("unpaired surrogate at byte offset %d", start)
+			}
+
+			low, err := strconv.ParseUint(string(data[i+3:i+7]), 16, 32)
+			if err != nil {
+				return nil, fmt.Errorf("unpaired surrogate at byte offset %d", start)
+			}
+
+			unescaped = utf8.AppendRune(unescaped, utf16.DecodeRune(r, rune(low)))
+			i += 6
+		default:
+			unescaped = utf8.AppendRune(unescaped, r)
+		}
+	}
+
+	return unescaped, nil
+}
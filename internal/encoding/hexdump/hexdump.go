@@ -0,0 +1,223 @@
+// Copyright (C) 2022 Mya Pitzeruse
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package hexdump
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"go.pitz.tech/em/internal/encoding/buffered"
+)
+
+// NewEncoder returns an encoder that renders data in the canonical hex+ASCII format used by `hexdump -C`. Each line
+// contains width bytes, split into groups of group bytes. The encoder must be closed to flush the trailing line.
+func NewEncoder(writer io.Writer, width, group int) *Encoder {
+	if width <= 0 {
+		width = 16
+	}
+
+	if group <= 0 {
+		group = width
+	}
+
+	return &Encoder{
+		writer: writer,
+		width:  width,
+		group:  group,
+		line:   make([]byte, 0, width),
+	}
+}
+
+type Encoder struct {
+	writer io.Writer
+	width  int
+	group  int
+	offset int
+	line   []byte
+}
+
+func (e *Encoder) Write(p []byte) (n int, err error) {
+	for len(p) > 0 {
+		size := e.width - len(e.line)
+		if size > len(p) {
+			size = len(p)
+		}
+
+		e.line = append(e.line, p[:size]...)
+		p = p[size:]
+		n += size
+
+		if len(e.line) == e.width {
+			if err = e.flush(); err != nil {
+				return n, err
+			}
+		}
+	}
+
+	return n, nil
+}
+
+func (e *Encoder) flush() error {
+	buf := &strings.Builder{}
+	_, _ = fmt.Fprintf(buf, "%08x ", e.offset)
+
+	for i := 0; i < e.width; i++ {
+		if i%e.group == 0 {
+			buf.WriteByte(' ')
+		}
+
+		if i < len(e.line) {
+			_, _ = fmt.Fprintf(buf, "%02x ", e.line[i])
+		} else {
+			buf.WriteString("   ")
+		}
+	}
+
+	buf.WriteString(" |")
+	for _, b := range e.line {
+		if b < 0x20 || b > 0x7e {
+			b = '.'
+		}
+
+		buf.WriteByte(b)
+	}
+	buf.WriteString("|\n")
+
+	e.offset += len(e.line)
+	e.line = e.line[:0]
+
+	_, err := io.WriteString(e.writer, buf.String())
+	return err
+}
+
+// Close flushes any partial line and writes the final offset.
+func (e *Encoder) Close() error {
+	if len(e.line) > 0 {
+		if err := e.flush(); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(e.writer, "%08x\n", e.offset)
+	return err
+}
+
+// maxElided bounds how many bytes a single `*` marker may expand to. The offset following the marker comes from the
+// input, so without a limit a single line could claim an arbitrarily large run of repeated data.
+const maxElided = 256 << 20
+
+// NewDecoder returns a decoder that parses hexdump output back into the original bytes. Both `hexdump -C` and
+// `xxd` styles are supported, including the `*` marker hexdump uses to elide repeated lines.
+func NewDecoder(reader io.Reader) io.Reader {
+	return buffered.NewDecoder(reader, Decode)
+}
+
+// Decode parses a complete hexdump.
+func Decode(data []byte) ([]byte, error) {
+	var decoded, previous []byte
+	repeat := false
+
+	for num, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if strings.TrimSpace(line) == "*" {
+			repeat = true
+			continue
+		}
+
+		offset, values, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", num+1, err)
+		}
+
+		if repeat {
+			elided := offset - len(decoded)
+
+			switch {
+			case elided < 0:
+				return nil, fmt.Errorf("line %d: offset %08x goes backwards", num+1, offset)
+			case elided > maxElided:
+				return nil, fmt.Errorf("line %d: repeat marker elides more than %d bytes", num+1, maxElided)
+			case elided > 0 && len(previous) == 0:
+				return nil, fmt.Errorf("line %d: repeat marker without a preceding line", num+1)
+			case elided > 0 && elided%len(previous) != 0:
+				return nil, fmt.Errorf("line %d: offset %08x does not follow whole repeated lines", num+1, offset)
+			case elided > 0:
+				decoded = append(decoded, bytes.Repeat(previous, elided/len(previous))...)
+			}
+
+			repeat = false
+		}
+
+		if offset != len(decoded) {
+			return nil, fmt.Errorf("line %d: expected offset %08x, got %08x", num+1, len(decoded), offset)
+		}
+
+		decoded = append(decoded, values...)
+		if len(values) > 0 {
+			previous = values
+		}
+	}
+
+	return decoded, nil
+}
+
+func parseLine(line string) (offset int, values []byte, err error) {
+	fields := strings.SplitN(line, " ", 2)
+
+	parsed, err := strconv.ParseUint(strings.TrimSuffix(fields[0], ":"), 16, 64)
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid offset %q", fields[0])
+	}
+
+	offset = int(parsed)
+
+	if len(fields) == 1 {
+		return offset, nil, nil
+	}
+
+	// strip the ASCII gutter. xxd marks its offsets with a trailing colon and separates the gutter with two spaces,
+	// while hexdump -C delimits the gutter with pipes. Either gutter may itself contain pipes or spaces.
+	columns := fields[1]
+	if strings.HasSuffix(fields[0], ":") {
+		columns = strings.TrimLeft(columns, " ")
+		if idx := strings.Index(columns, "  "); idx >= 0 {
+			columns = columns[:idx]
+		}
+	} else if idx := strings.IndexByte(columns, '|'); idx >= 0 {
+		columns = columns[:idx]
+	}
+
+	for _, group := range strings.Fields(columns) {
+		decoded, err := hex.DecodeString(group)
+		if err != nil {
+			return 0, nil, fmt.Errorf("invalid byte group %q", group)
+		}
+
+		values = append(values, decoded...)
+	}
+
+	return offset, values, nil
+}
+
+var _ io.WriteCloser = &Encoder{}
@@ -0,0 +1,98 @@
+// Copyright (C) 2022 Mya Pitzeruse
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package literal
+
+import (
+	"fmt"
+	"strings"
+
+	"go.pitz.tech/em/internal/encoding/buffered"
+)
+
+// Language describes how a byte array literal is rendered in a given language.
+type Language struct {
+	Header func(name string, length int) string
+	Indent string
+	Footer func(name string, length int) string
+}
+
+var (
+	Go = Language{
+		Header: func(name string, _ int) string {
+			return fmt.Sprintf("var %s = []byte{\n", name)
+		},
+		Indent: "\t",
+		Footer: func(string, int) string {
+			return "}\n"
+		},
+	}
+
+	C = Language{
+		Header: func(name string, _ int) string {
+			return fmt.Sprintf("unsigned char %s[] = {\n", name)
+		},
+		Indent: "  ",
+		Footer: func(name string, length int) string {
+			return fmt.Sprintf("};\nunsigned int %s_len = %d;\n", name, length)
+		},
+	}
+
+	Rust = Language{
+		Header: func(name string, length int) string {
+			return fmt.Sprintf("pub const %s: [u8; %d] = [\n", strings.ToUpper(name), length)
+		},
+		Indent: "    ",
+		Footer: func(string, int) string {
+			return "];\n"
+		},
+	}
+)
+
+// Encode returns a function that renders data as a byte array literal in the provided language, assigned to a
+// variable with the given name. Each line contains at most width bytes.
+func Encode(lang Language, name string, width int) buffered.Func {
+	if width <= 0 {
+		width = 12
+	}
+
+	return func(data []byte) ([]byte, error) {
+		out := &strings.Builder{}
+		out.WriteString(lang.Header(name, len(data)))
+
+		for i := 0; i < len(data); i += width {
+			end := i + width
+			if end > len(data) {
+				end = len(data)
+			}
+
+			out.WriteString(lang.Indent)
+
+			for j, b := range data[i:end] {
+				if j > 0 {
+					out.WriteByte(' ')
+				}
+
+				_, _ = fmt.Fprintf(out, "0x%02x,", b)
+			}
+
+			out.WriteByte('\n')
+		}
+
+		out.WriteString(lang.Footer(name, len(data)))
+
+		return []byte(out.String()), nil
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright (C) 2022 Mya Pitzeruse
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package morse translates text to and from International Morse code. Morse code has no notion of case, so decoded
+// letters are always upper case.
+package morse
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var codes = map[byte]string{
+	'A': ".-", 'B': "-...", 'C': "-.-.", 'D': "-..", 'E': ".", 'F': "..-.", 'G': "--.", 'H': "....", 'I': "..",
+	'J': ".---", 'K': "-.-", 'L': ".-..", 'M': "--", 'N': "-.", 'O': "---", 'P': ".--.", 'Q': "--.-", 'R': ".-.",
+	'S': "...", 'T': "-", 'U': "..-", 'V': "...-", 'W': ".--", 'X': "-..-", 'Y': "-.--", 'Z': "--..",
+
+	'0': "-----", '1': ".----", '2': "..---", '3': "...--", '4': "....-",
+	'5': ".....", '6': "-....", '7': "--...", '8': "---..", '9': "----.",
+
+	'.': ".-.-.-", ',': "--..--", '?': "..--..", '\'': ".----.", '!': "-.-.--", '/': "-..-.", '(': "-.--.",
+	')': "-.--.-", '&': ".-...", ':': "---...", ';': "-.-.-.", '=': "-...-", '+': ".-.-.", '-': "-....-",
+	'_': "..--.-", '"': ".-..-.", '$': "...-..-", '@': ".--.-.",
+}
+
+var letters = map[string]byte{}
+
+func init() {
+	for letter, code := range codes {
+		letters[code] = letter
+	}
+}
+
+// wordSeparator separates encoded words.
+const wordSeparator = "/"
+
+// Encode translates text into Morse code. Letters are separated by a space and words by a slash.
+func Encode(data []byte) ([]byte, error) {
+	words := strings.Fields(string(data))
+	encoded := make([]string, 0, len(words))
+
+	for _, word := range words {
+		symbols := make([]string, 0, len(word))
+
+		for _, r := range word {
+			code, ok := "", false
+			if r < utf8.RuneSelf {
+				code, ok = codes[byte(unicode.ToUpper(r))]
+			}
+
+			if !ok {
+				return nil, fmt.Errorf("%q cannot be represented in morse code", r)
+			}
+
+			symbols = append(symbols, code)
+		}
+
+		encoded = append(encoded, strings.Join(symbols, " "))
+	}
+
+	return []byte(strings.Join(encoded, " "+wordSeparator+" ")), nil
+}
+
+// Decode translates Morse code back into text. Both "/" and "|" are accepted as word separators.
+func Decode(data []byte) ([]byte, error) {
+	out := make([]byte, 0, len(data)/3)
+	pending := false
+
+	for _, symbol := range strings.Fields(string(data)) {
+		if symbol == wordSeparator || symbol == "|" {
+			pending = len(out) > 0
+			continue
+		}
+
+		letter, ok := letters[symbol]
+		if !ok {
+			return nil, fmt.Errorf("unrecognized morse code: %s", symbol)
+		}
+
+		if pending {
+			out = append(out, ' ')
+			pending = false
+		}
+
+		out = append(out, letter)
+	}
+
+	return out, nil
+}
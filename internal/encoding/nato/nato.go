@@ -0,0 +1,121 @@
+// Copyright (C) 2022 Mya Pitzeruse
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package nato spells text out using the NATO phonetic alphabet. Upper case letters are written in upper case (ALFA)
+// and lower case letters in lower case (alfa) so that case survives a round trip. Digits, whitespace, and punctuation
+// are spelled out by name.
+package nato
+
+import (
+	"fmt"
+	"strings"
+)
+
+var letterWords = []string{
+	"alfa", "bravo", "charlie", "delta", "echo", "foxtrot", "golf", "hotel", "india", "juliett", "kilo", "lima", "mike",
+	"november", "oscar", "papa", "quebec", "romeo", "sierra", "tango", "uniform", "victor", "whiskey", "x-ray",
+	"yankee", "zulu",
+}
+
+var digitWords = []string{
+	"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine",
+}
+
+var symbolWords = map[byte]string{
+	' ': "space", '\t': "tab", '\n': "newline", '\r': "return",
+	'!': "exclamation", '"': "quote", '#': "hash", '$': "dollar", '%': "percent", '&': "ampersand",
+	'\'': "apostrophe", '(': "open-paren", ')': "close-paren", '*': "asterisk", '+': "plus", ',': "comma",
+	'-': "dash", '.': "period", '/': "slash", ':': "colon", ';': "semicolon", '<': "less-than", '=': "equals",
+	'>': "greater-than", '?': "question", '@': "at", '[': "open-bracket", '\\': "backslash", ']': "close-bracket",
+	'^': "caret", '_': "underscore", '`': "backtick", '{': "open-brace", '|': "pipe", '}': "close-brace",
+	'~': "tilde",
+}
+
+// aliases are common alternate spellings that are accepted when decoding.
+var aliases = map[string]string{
+	"alpha":  "alfa",
+	"juliet": "juliett",
+	"xray":   "x-ray",
+	"niner":  "nine",
+	"dot":    "period",
+}
+
+var bytesByWord = map[string]byte{}
+
+func init() {
+	for i, word := range letterWords {
+		bytesByWord[word] = 'a' + byte(i)
+	}
+
+	for i, word := range digitWords {
+		bytesByWord[word] = '0' + byte(i)
+	}
+
+	for b, word := range symbolWords {
+		bytesByWord[word] = b
+	}
+}
+
+// Encode spells out each byte of data as a word, separated by spaces.
+func Encode(data []byte) ([]byte, error) {
+	words := make([]string, 0, len(data))
+
+	for _, b := range data {
+		switch {
+		case b >= 'a' && b <= 'z':
+			words = append(words, letterWords[b-'a'])
+		case b >= 'A' && b <= 'Z':
+			words = append(words, strings.ToUpper(letterWords[b-'A']))
+		case b >= '0' && b <= '9':
+			words = append(words, digitWords[b-'0'])
+		default:
+			word, ok := symbolWords[b]
+			if !ok {
+				return nil, fmt.Errorf("byte 0x%02x cannot be spelled with the nato alphabet", b)
+			}
+
+			words = append(words, word)
+		}
+	}
+
+	return []byte(strings.Join(words, " ")), nil
+}
+
+// Decode translates spelled out words back into text. Words written entirely in upper case produce upper case
+// letters, all others produce lower case letters.
+func Decode(data []byte) ([]byte, error) {
+	words := strings.Fields(string(data))
+	out := make([]byte, 0, len(words))
+
+	for _, word := range words {
+		lower := strings.ToLower(word)
+		if alias, ok := aliases[lower]; ok {
+			lower = alias
+		}
+
+		b, ok := bytesByWord[lower]
+		if !ok {
+			return nil, fmt.Errorf("unrecognized nato word: %s", word)
+		}
+
+		if b >= 'a' && b <= 'z' && word == strings.ToUpper(word) {
+			b -= 'a' - 'A'
+		}
+
+		out = append(out, b)
+	}
+
+	return out, nil
+}
@@ -0,0 +1,81 @@
+// Copyright (C) 2022 Mya Pitzeruse
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package padding helps decoders accept input with or without trailing padding.
+package padding
+
+import (
+	"io"
+)
+
+// Pad is the padding character used by both base64 and base32.
+const Pad = '='
+
+// NewReader returns a reader that drops the padding at the end of the data read from reader, along with any whitespace
+// following it. Removing it allows the data to be decoded by an unpadded encoding whether or not it was padded to begin
+// with. Padding followed by more data is passed through untouched so that the decoder still rejects it.
+func NewReader(reader io.Reader) io.Reader {
+	return &stripReader{reader: reader}
+}
+
+type stripReader struct {
+	reader  io.Reader
+	scratch []byte
+	// held contains padding, and any whitespace after it, that will be dropped if nothing else follows
+	held []byte
+	// ready contains data that can be returned to the caller
+	ready []byte
+	err   error
+}
+
+func (r *stripReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	for len(r.ready) == 0 && r.err == nil {
+		if len(r.scratch) < len(p) {
+			r.scratch = make([]byte, len(p))
+		}
+
+		n, err := r.reader.Read(r.scratch[:len(p)])
+
+		for _, b := range r.scratch[:n] {
+			switch {
+			case b == Pad, len(r.held) > 0 && isSpace(b):
+				r.held = append(r.held, b)
+			default:
+				r.ready = append(r.ready, r.held...)
+				r.ready = append(r.ready, b)
+				r.held = r.held[:0]
+			}
+		}
+
+		r.err = err
+	}
+
+	if len(r.ready) == 0 {
+		return 0, r.err
+	}
+
+	n := copy(p, r.ready)
+	r.ready = r.ready[n:]
+
+	return n, nil
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\r' || b == '\n'
+}
@@ -0,0 +1,118 @@
+// Copyright (C) 2022 Mya Pitzeruse
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package qr
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/skip2/go-qrcode"
+
+	"go.pitz.tech/em/internal/encoding/buffered"
+)
+
+// moduleSize is the number of pixels used to draw each module when writing an image.
+const moduleSize = 8
+
+// Options configures how QR codes are rendered.
+type Options struct {
+	// Level is the error correction level (low, medium, quartile, high).
+	Level string
+	// File, when set, receives a PNG or SVG image (chosen by extension) instead of rendering to the terminal.
+	File string
+	// Invert swaps light and dark modules for terminals with a light background.
+	Invert bool
+}
+
+// ParseLevel converts the name of an error correction level into its recovery level. Both the QR specification names
+// (L, M, Q, H) and the names used by the underlying library are accepted.
+func ParseLevel(level string) (qrcode.RecoveryLevel, error) {
+	switch strings.ToLower(level) {
+	case "l", "low":
+		return qrcode.Low, nil
+	case "", "m", "medium":
+		return qrcode.Medium, nil
+	case "q", "quartile":
+		return qrcode.High, nil
+	case "h", "high", "highest":
+		return qrcode.Highest, nil
+	}
+
+	return 0, fmt.Errorf("unrecognized qr error correction level: %s", level)
+}
+
+// Encode returns a function that renders data as a QR code. Codes are drawn with Unicode half-block characters, two
+// rows of modules per line, unless a file is configured.
+func Encode(opts Options) buffered.Func {
+	return func(data []byte) ([]byte, error) {
+		level, err := ParseLevel(opts.Level)
+		if err != nil {
+			return nil, err
+		}
+
+		code, err := qrcode.New(string(data), level)
+		if err != nil {
+			return nil, err
+		}
+
+		if opts.File == "" {
+			return []byte(code.ToSmallString(opts.Invert)), nil
+		}
+
+		var image []byte
+
+		switch ext := strings.ToLower(filepath.Ext(opts.File)); ext {
+		case ".png":
+			image, err = code.PNG(-moduleSize)
+		case ".svg":
+			image = SVG(code.Bitmap())
+		default:
+			return nil, fmt.Errorf("unsupported qr image format: %q (use .png or .svg)", ext)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, os.WriteFile(opts.File, image, 0o644)
+	}
+}
+
+// SVG renders a bitmap of dark modules as a scalable image.
+func SVG(bitmap [][]bool) []byte {
+	size := len(bitmap)
+
+	buf := &bytes.Buffer{}
+	_, _ = fmt.Fprintf(buf, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" width="%d" height="%d" shape-rendering="crispEdges">`+"\n",
+		size, size, size*moduleSize, size*moduleSize)
+	_, _ = fmt.Fprintf(buf, `<rect width="%d" height="%d" fill="#fff"/>`+"\n", size, size)
+	buf.WriteString(`<path fill="#000" d="`)
+
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				_, _ = fmt.Fprintf(buf, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+
+	buf.WriteString("\"/>\n</svg>\n")
+
+	return buf.Bytes()
+}
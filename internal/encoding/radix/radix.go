@@ -0,0 +1,91 @@
+// Copyright (C) 2022 Mya Pitzeruse
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package radix writes bytes as space separated binary (base 2) or octal (base 8) numbers.
+package radix
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+func encode(data []byte, base, digits int) []byte {
+	out := make([]byte, 0, len(data)*(digits+1))
+
+	for i, b := range data {
+		if i > 0 {
+			out = append(out, ' ')
+		}
+
+		s := strconv.FormatUint(uint64(b), base)
+		for pad := len(s); pad < digits; pad++ {
+			out = append(out, '0')
+		}
+
+		out = append(out, s...)
+	}
+
+	return out
+}
+
+// decode parses whitespace separated numbers. Fields longer than digits are split into groups of digits, so both
+// "01101000 01101001" and "0110100001101001" are accepted.
+func decode(data []byte, base, digits int) ([]byte, error) {
+	out := make([]byte, 0, len(data)/digits)
+
+	for _, field := range bytes.Fields(data) {
+		if len(field) > digits && len(field)%digits != 0 {
+			return nil, fmt.Errorf("invalid base %d value: %s", base, field)
+		}
+
+		for len(field) > 0 {
+			size := digits
+			if len(field) < size {
+				size = len(field)
+			}
+
+			v, err := strconv.ParseUint(string(field[:size]), base, 8)
+			if err != nil {
+				return nil, fmt.Errorf("invalid base %d value: %s", base, field[:size])
+			}
+
+			out = append(out, byte(v))
+			field = field[size:]
+		}
+	}
+
+	return out, nil
+}
+
+// EncodeBinary writes each byte as eight binary digits.
+func EncodeBinary(data []byte) ([]byte, error) {
+	return encode(data, 2, 8), nil
+}
+
+// DecodeBinary parses bytes written as binary digits.
+func DecodeBinary(data []byte) ([]byte, error) {
+	return decode(data, 2, 8)
+}
+
+// EncodeOctal writes each byte as three octal digits.
+func EncodeOctal(data []byte) ([]byte, error) {
+	return encode(data, 8, 3), nil
+}
+
+// DecodeOctal parses bytes written as octal digits.
+func DecodeOctal(data []byte) ([]byte, error) {
+	return decode(data, 8, 3)
+}
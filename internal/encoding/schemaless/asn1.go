@@ -0,0 +1,281 @@
+// Copyright (C) 2022 Mya Pitzeruse
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package schemaless
+
+import (
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"unicode/utf16"
+)
+
+// Node is a single ASN.1 TLV (tag, length, value). Constructed nodes list their children. OCTET STRING and BIT STRING
+// values that themselves contain DER are expanded into Encapsulated.
+type Node struct {
+	Class        string `json:"class"`
+	Tag          uint64 `json:"tag"`
+	Type         string `json:"type,omitempty"`
+	Constructed  bool   `json:"constructed,omitempty"`
+	Value        any    `json:"value,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Hex          string `json:"hex,omitempty"`
+	UnusedBits   *int   `json:"unused_bits,omitempty"`
+	Children     []Node `json:"children,omitempty"`
+	Encapsulated []Node `json:"encapsulated,omitempty"`
+}
+
+var classes = []string{"universal", "application", "context", "private"}
+
+var universalTypes = map[uint64]string{
+	1:  "BOOLEAN",
+	2:  "INTEGER",
+	3:  "BIT STRING",
+	4:  "OCTET STRING",
+	5:  "NULL",
+	6:  "OBJECT IDENTIFIER",
+	10: "ENUMERATED",
+	12: "UTF8String",
+	16: "SEQUENCE",
+	17: "SET",
+	18: "NumericString",
+	19: "PrintableString",
+	20: "T61String",
+	22: "IA5String",
+	23: "UTCTime",
+	24: "GeneralizedTime",
+	26: "VisibleString",
+	28: "UniversalString",
+	30: "BMPString",
+}
+
+// oidNames contains the names of commonly encountered object identifiers, mostly from X.509 certificates.
+var oidNames = map[string]string{
+	"1.2.840.113549.1.1.1":  "rsaEncryption",
+	"1.2.840.113549.1.1.5":  "sha1WithRSAEncryption",
+	"1.2.840.113549.1.1.11": "sha256WithRSAEncryption",
+	"1.2.840.113549.1.1.12": "sha384WithRSAEncryption",
+	"1.2.840.113549.1.1.13": "sha512WithRSAEncryption",
+	"1.2.840.113549.1.9.1":  "emailAddress",
+	"1.2.840.10045.2.1":     "ecPublicKey",
+	"1.2.840.10045.3.1.7":   "prime256v1",
+	"1.2.840.10045.4.3.2":   "ecdsa-with-SHA256",
+	"1.2.840.10045.4.3.3":   "ecdsa-with-SHA384",
+	"1.3.101.112":           "ed25519",
+	"1.3.132.0.34":          "secp384r1",
+	"2.5.4.3":               "commonName",
+	"2.5.4.6":               "countryName",
+	"2.5.4.7":               "localityName",
+	"2.5.4.8":               "stateOrProvinceName",
+	"2.5.4.10":              "organizationName",
+	"2.5.4.11":              "organizationalUnitName",
+	"2.5.29.14":             "subjectKeyIdentifier",
+	"2.5.29.15":             "keyUsage",
+	"2.5.29.17":             "subjectAltName",
+	"2.5.29.19":             "basicConstraints",
+	"2.5.29.31":             "cRLDistributionPoints",
+	"2.5.29.32":             "certificatePolicies",
+	"2.5.29.35":             "authorityKeyIdentifier",
+	"2.5.29.37":             "extKeyUsage",
+	"1.3.6.1.5.5.7.1.1":     "authorityInfoAccess",
+	"1.3.6.1.5.5.7.3.1":     "serverAuth",
+	"1.3.6.1.5.5.7.3.2":     "clientAuth",
+}
+
+// DecodeASN1 walks DER encoded data without a schema, returning an indented JSON array of the top level nodes.
+func DecodeASN1(data []byte) ([]byte, error) {
+	nodes, err := parseDER(data, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.MarshalIndent(nodes, "", "  ")
+}
+
+// parseDER parses a sequence of TLVs. The base offset is only used for error reporting.
+func parseDER(data []byte, base, depth int) ([]Node, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("structure nested too deeply")
+	}
+
+	nodes := make([]Node, 0)
+
+	for offset := 0; offset < len(data); {
+		node, size, err := parseTLV(data[offset:], base+offset, depth)
+		if err != nil {
+			return nil, err
+		}
+
+		nodes = append(nodes, node)
+		offset += size
+	}
+
+	return nodes, nil
+}
+
+func parseTLV(data []byte, offset, depth int) (node Node, size int, err error) {
+	node.Class = classes[data[0]>>6]
+	node.Constructed = data[0]&0x20 != 0
+	node.Tag = uint64(data[0] & 0x1f)
+
+	i := 1
+	if node.Tag == 0x1f {
+		node.Tag = 0
+
+		for {
+			if i >= len(data) || i > 8 {
+				return node, 0, fmt.Errorf("invalid tag at byte offset %d", offset)
+			}
+
+			node.Tag = node.Tag<<7 | uint64(data[i]&0x7f)
+			i++
+
+			if data[i-1]&0x80 == 0 {
+				break
+			}
+		}
+	}
+
+	if i >= len(data) {
+		return node, 0, fmt.Errorf("truncated length at byte offset %d", offset+i)
+	}
+
+	length := int(data[i])
+	i++
+
+	if length&0x80 != 0 {
+		count := length & 0x7f
+
+		switch {
+		case count == 0:
+			return node, 0, fmt.Errorf("indefinite length at byte offset %d is not valid DER", offset+i-1)
+		case count > 4 || i+count > len(data):
+			return node, 0, fmt.Errorf("invalid length at byte offset %d", offset+i-1)
+		}
+
+		length = 0
+		for _, b := range data[i : i+count] {
+			length = length<<8 | int(b)
+		}
+
+		i += count
+	}
+
+	if length < 0 || length > len(data)-i {
+		return node, 0, fmt.Errorf("length at byte offset %d exceeds the available data", offset)
+	}
+
+	content := data[i : i+length]
+
+	if node.Class == "universal" {
+		node.Type = universalTypes[node.Tag]
+	}
+
+	switch {
+	case node.Constructed:
+		node.Children, err = parseDER(content, offset+i, depth+1)
+	case node.Class != "universal":
+		describeBytes(&node, content)
+	default:
+		err = describeUniversal(&node, data[:i+length], content, offset+i, depth)
+	}
+
+	return node, i + length, err
+}
+
+func describeUniversal(node *Node, tlv, content []byte, offset, depth int) error {
+	switch node.Tag {
+	case 1:
+		node.Value = len(content) > 0 && content[0] != 0
+	case 2, 10:
+		value := new(big.Int).SetBytes(content)
+		if len(content) > 0 && content[0]&0x80 != 0 {
+			value.Sub(value, new(big.Int).Lsh(big.NewInt(1), uint(len(content)*8)))
+		}
+
+		node.Value = json.Number(value.String())
+	case 3:
+		if len(content) == 0 {
+			return fmt.Errorf("empty bit string at byte offset %d", offset)
+		}
+
+		unused := int(content[0])
+		node.UnusedBits = &unused
+		node.Hex = hex.EncodeToString(content[1:])
+
+		if unused == 0 {
+			node.Encapsulated = encapsulated(content[1:], offset+1, depth)
+		}
+	case 4:
+		node.Hex = hex.EncodeToString(content)
+		node.Encapsulated = encapsulated(content, offset, depth)
+	case 5:
+	case 6:
+		var oid asn1.ObjectIdentifier
+		if _, err := asn1.Unmarshal(tlv, &oid); err != nil {
+			return fmt.Errorf("invalid object identifier at byte offset %d", offset)
+		}
+
+		node.Value = oid.String()
+		node.Name = oidNames[oid.String()]
+	case 30:
+		runes := make([]uint16, len(content)/2)
+		for i := range runes {
+			runes[i] = uint16(content[2*i])<<8 | uint16(content[2*i+1])
+		}
+
+		node.Value = string(utf16.Decode(runes))
+	case 28:
+		runes := make([]rune, len(content)/4)
+		for i := range runes {
+			runes[i] = rune(content[4*i])<<24 | rune(content[4*i+1])<<16 | rune(content[4*i+2])<<8 | rune(content[4*i+3])
+		}
+
+		node.Value = string(runes)
+	case 12, 18, 19, 20, 22, 23, 24, 26:
+		node.Value = string(content)
+	default:
+		describeBytes(node, content)
+	}
+
+	return nil
+}
+
+// describeBytes renders content as a string when it is printable, and as hex otherwise.
+func describeBytes(node *Node, content []byte) {
+	if len(content) > 0 && printable(content) {
+		node.Value = string(content)
+		return
+	}
+
+	node.Hex = hex.EncodeToString(content)
+}
+
+// encapsulated attempts to parse DER nested within a string type. Only content that starts with a SEQUENCE or SET
+// and parses completely is considered, which avoids misinterpreting arbitrary bytes.
+func encapsulated(content []byte, offset, depth int) []Node {
+	if len(content) < 2 || (content[0] != 0x30 && content[0] != 0x31) {
+		return nil
+	}
+
+	nodes, err := parseDER(content, offset, depth+1)
+	if err != nil {
+		return nil
+	}
+
+	return nodes
+}
@@ -0,0 +1,199 @@
+// Copyright (C) 2022 Mya Pitzeruse
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package schemaless
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxDepth bounds how deeply nested messages are explored.
+const maxDepth = 64
+
+// Field is a single protobuf field as it appears on the wire. Only the members relevant to the wire type are set.
+// Length delimited fields are rendered as a nested message when possible, then as a string, and finally as bytes.
+type Field struct {
+	Number   uint64   `json:"field"`
+	WireType string   `json:"wire_type"`
+	Varint   *uint64  `json:"varint,omitempty"`
+	ZigZag   *int64   `json:"zigzag,omitempty"`
+	Fixed64  *uint64  `json:"fixed64,omitempty"`
+	Fixed32  *uint32  `json:"fixed32,omitempty"`
+	Double   *float64 `json:"double,omitempty"`
+	Float    *float32 `json:"float,omitempty"`
+	Message  []Field  `json:"message,omitempty"`
+	String   *string  `json:"string,omitempty"`
+	Bytes    *string  `json:"bytes,omitempty"`
+	Group    []Field  `json:"group,omitempty"`
+}
+
+// DecodeProtobuf walks a protobuf message without a schema, returning an indented JSON array of its fields.
+func DecodeProtobuf(data []byte) ([]byte, error) {
+	fields, rest, err := parseMessage(data, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("unexpected end group at byte offset %d", len(data)-len(rest))
+	}
+
+	return json.MarshalIndent(fields, "", "  ")
+}
+
+// parseMessage reads fields until data is exhausted or the end of the provided group is found. Any data
+// following the end of the group is returned.
+func parseMessage(data []byte, group uint64, depth int) (fields []Field, rest []byte, err error) {
+	if depth > maxDepth {
+		return nil, nil, fmt.Errorf("message nested too deeply")
+	}
+
+	fields = make([]Field, 0)
+	offset := 0
+
+	for offset < len(data) {
+		tag, n := binary.Uvarint(data[offset:])
+		if n <= 0 {
+			return nil, nil, fmt.Errorf("invalid tag at byte offset %d", offset)
+		}
+
+		offset += n
+
+		field := Field{Number: tag >> 3}
+		if field.Number == 0 {
+			return nil, nil, fmt.Errorf("invalid field number 0 at byte offset %d", offset-n)
+		}
+
+		switch tag & 7 {
+		case 0:
+			value, n := binary.Uvarint(data[offset:])
+			if n <= 0 {
+				return nil, nil, fmt.Errorf("invalid varint at byte offset %d", offset)
+			}
+
+			offset += n
+			zigzag := int64(value>>1) ^ -int64(value&1)
+
+			field.WireType = "varint"
+			field.Varint = &value
+			field.ZigZag = &zigzag
+		case 1:
+			if offset+8 > len(data) {
+				return nil, nil, fmt.Errorf("truncated fixed64 at byte offset %d", offset)
+			}
+
+			value := binary.LittleEndian.Uint64(data[offset:])
+			double := math.Float64frombits(value)
+			offset += 8
+
+			field.WireType = "fixed64"
+			field.Fixed64 = &value
+			if !math.IsNaN(double) && !math.IsInf(double, 0) {
+				field.Double = &double
+			}
+		case 2:
+			length, n := binary.Uvarint(data[offset:])
+			if n <= 0 || length > uint64(len(data)-offset-n) {
+				return nil, nil, fmt.Errorf("invalid length at byte offset %d", offset)
+			}
+
+			offset += n
+			payload := data[offset : offset+int(length)]
+			offset += int(length)
+
+			field.WireType = "len"
+			describePayload(&field, payload, depth)
+		case 3:
+			children, remaining, err := parseMessage(data[offset:], field.Number, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			offset = len(data) - len(remaining)
+
+			field.WireType = "group"
+			field.Group = children
+		case 4:
+			if field.Number != group {
+				return nil, nil, fmt.Errorf("unexpected end group at byte offset %d", offset-n)
+			}
+
+			return fields, data[offset:], nil
+		case 5:
+			if offset+4 > len(data) {
+				return nil, nil, fmt.Errorf("truncated fixed32 at byte offset %d", offset)
+			}
+
+			value := binary.LittleEndian.Uint32(data[offset:])
+			float := math.Float32frombits(value)
+			offset += 4
+
+			field.WireType = "fixed32"
+			field.Fixed32 = &value
+			if !math.IsNaN(float64(float)) && !math.IsInf(float64(float), 0) {
+				field.Float = &float
+			}
+		default:
+			return nil, nil, fmt.Errorf("invalid wire type %d at byte offset %d", tag&7, offset-n)
+		}
+
+		fields = append(fields, field)
+	}
+
+	if group != 0 {
+		return nil, nil, fmt.Errorf("missing end group for field %d", group)
+	}
+
+	return fields, nil, nil
+}
+
+func describePayload(field *Field, payload []byte, depth int) {
+	if len(payload) > 0 && printable(payload) {
+		str := string(payload)
+		field.String = &str
+
+		return
+	}
+
+	if len(payload) > 0 {
+		if message, rest, err := parseMessage(payload, 0, depth+1); err == nil && len(rest) == 0 {
+			field.Message = message
+			return
+		}
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(payload)
+	field.Bytes = &encoded
+}
+
+func printable(data []byte) bool {
+	if !utf8.Valid(data) {
+		return false
+	}
+
+	for _, r := range string(data) {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+
+	return true
+}
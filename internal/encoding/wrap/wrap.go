@@ -0,0 +1,78 @@
+// Copyright (C) 2022 Mya Pitzeruse
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wrap
+
+import (
+	"io"
+)
+
+// NewWriter returns a writer that inserts a newline after every width bytes written to it. The writer must be closed
+// to terminate the last line.
+func NewWriter(writer io.Writer, width int) *Writer {
+	return &Writer{
+		writer: writer,
+		width:  width,
+	}
+}
+
+type Writer struct {
+	writer io.Writer
+	width  int
+	column int
+}
+
+func (w *Writer) Write(p []byte) (n int, err error) {
+	for len(p) > 0 {
+		size := w.width - w.column
+		if size > len(p) {
+			size = len(p)
+		}
+
+		written, err := w.writer.Write(p[:size])
+		n += written
+		w.column += written
+
+		if err != nil {
+			return n, err
+		}
+
+		p = p[size:]
+
+		if w.column == w.width {
+			if _, err = w.writer.Write([]byte("\n")); err != nil {
+				return n, err
+			}
+
+			w.column = 0
+		}
+	}
+
+	return n, nil
+}
+
+// Close terminates any partially written line.
+func (w *Writer) Close() error {
+	if w.column == 0 {
+		return nil
+	}
+
+	w.column = 0
+	_, err := w.writer.Write([]byte("\n"))
+
+	return err
+}
+
+var _ io.WriteCloser = &Writer{}
@@ -0,0 +1,148 @@
+// Copyright (C) 2022 Mya Pitzeruse
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package jwt
+
+import (
+	"bufio"
+	"crypto"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/urfave/cli/v2"
+
+	"go.pitz.tech/lib/flagset"
+)
+
+type VerifyConfig struct {
+	Key        string `json:"key"         usage:"path to a pem encoded public key or certificate"`
+	JWKS       string `json:"jwks"        usage:"path to a file containing a json web key set"`
+	Issuer     string `json:"issuer"      usage:"the expected issuer, also used for discovery when no key or jwks is given"`
+	Audience   string `json:"audience"    usage:"the expected audience of the token"`
+	SkipExpiry bool   `json:"skip_expiry" usage:"accept tokens that have already expired"`
+}
+
+func readToken(ctx *cli.Context) (string, error) {
+	if ctx.NArg() > 0 {
+		return ctx.Args().Get(0), nil
+	}
+
+	data, err := io.ReadAll(bufio.NewReader(ctx.App.Reader))
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(v)
+}
+
+var (
+	verifyConfig = &VerifyConfig{}
+
+	Command = &cli.Command{
+		Name:            "jwt",
+		Usage:           "Decode and verify JSON web tokens.",
+		HideHelpCommand: true,
+		Subcommands: []*cli.Command{
+			{
+				Name:            "decode",
+				Usage:           "Decode the header and claims of a token without verifying it.",
+				UsageText:       "em jwt decode [token]",
+				HideHelpCommand: true,
+				Action: func(ctx *cli.Context) error {
+					raw, err := readToken(ctx)
+					if err != nil {
+						return err
+					}
+
+					token, err := Decode(raw, time.Now())
+					if err != nil {
+						return err
+					}
+
+					return writeJSON(ctx.App.Writer, token)
+				},
+			},
+			{
+				Name:            "verify",
+				Usage:           "Verify the signature and claims of a token.",
+				UsageText:       "em jwt verify [--key file | --jwks file | --issuer url] [token]",
+				Flags:           flagset.ExtractPrefix("em", verifyConfig),
+				HideHelpCommand: true,
+				Action: func(ctx *cli.Context) error {
+					raw, err := readToken(ctx)
+					if err != nil {
+						return err
+					}
+
+					config := &oidc.Config{
+						ClientID:          verifyConfig.Audience,
+						SkipClientIDCheck: verifyConfig.Audience == "",
+						SkipExpiryCheck:   verifyConfig.SkipExpiry,
+						SkipIssuerCheck:   verifyConfig.Issuer == "",
+					}
+
+					var keys []crypto.PublicKey
+
+					switch {
+					case verifyConfig.Key != "":
+						keys, err = LoadPEM(verifyConfig.Key)
+					case verifyConfig.JWKS != "":
+						keys, err = LoadJWKS(verifyConfig.JWKS)
+					case verifyConfig.Issuer == "":
+						return fmt.Errorf("one of --key, --jwks, or --issuer is required")
+					}
+
+					if err != nil {
+						return err
+					}
+
+					var verifier *oidc.IDTokenVerifier
+					if keys != nil {
+						config.SupportedSigningAlgs = signingAlgorithms
+						verifier = oidc.NewVerifier(verifyConfig.Issuer, &oidc.StaticKeySet{PublicKeys: keys}, config)
+					} else {
+						provider, err := oidc.NewProvider(ctx.Context, verifyConfig.Issuer)
+						if err != nil {
+							return fmt.Errorf("failed to discover issuer: %w", err)
+						}
+
+						verifier = provider.Verifier(config)
+					}
+
+					if _, err = verifier.Verify(ctx.Context, Clean(raw)); err != nil {
+						return err
+					}
+
+					token, err := Decode(raw, time.Now())
+					if err != nil {
+						return err
+					}
+
+					return writeJSON(ctx.App.Writer, token)
+				},
+			},
+		},
+	}
+)
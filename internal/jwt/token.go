@@ -0,0 +1,109 @@
+// Copyright (C) 2022 Mya Pitzeruse
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package jwt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Token contains the decoded segments of a JSON web token. Registered time claims (exp, iat, nbf) are repeated in
+// Times in a human-readable form.
+type Token struct {
+	Header    map[string]any    `json:"header"`
+	Claims    map[string]any    `json:"claims"`
+	Times     map[string]string `json:"times,omitempty"`
+	Signature string            `json:"signature"`
+}
+
+// Clean removes surrounding whitespace and an optional "Bearer" prefix, so values copied from an Authorization
+// header can be used as is.
+func Clean(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if len(raw) > 7 && strings.EqualFold(raw[:7], "bearer ") {
+		raw = strings.TrimSpace(raw[7:])
+	}
+
+	return raw
+}
+
+// Decode splits a compact serialized token and decodes its header and claims without verifying the signature.
+func Decode(raw string, now time.Time) (*Token, error) {
+	parts := strings.Split(Clean(raw), ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("expected 3 token segments, got %d", len(parts))
+	}
+
+	token := &Token{
+		Signature: parts[2],
+	}
+
+	if err := decodeSegment(parts[0], &token.Header); err != nil {
+		return nil, fmt.Errorf("invalid header: %w", err)
+	}
+
+	if err := decodeSegment(parts[1], &token.Claims); err != nil {
+		return nil, fmt.Errorf("invalid claims: %w", err)
+	}
+
+	for _, claim := range []string{"exp", "iat", "nbf"} {
+		if value, ok := token.Claims[claim].(json.Number); ok {
+			if token.Times == nil {
+				token.Times = make(map[string]string)
+			}
+
+			token.Times[claim] = formatTime(value, now)
+		}
+	}
+
+	return token, nil
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(segment, "="))
+	if err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	return dec.Decode(v)
+}
+
+// formatTime renders a NumericDate claim as a local timestamp along with how far it is from now.
+func formatTime(value json.Number, now time.Time) string {
+	seconds, err := value.Float64()
+	if err != nil {
+		return value.String()
+	}
+
+	t := time.Unix(0, int64(seconds*float64(time.Second))).Local()
+	delta := t.Sub(now).Round(time.Second)
+
+	switch {
+	case delta > 0:
+		return fmt.Sprintf("%s (in %s)", t.Format(time.RFC3339), delta)
+	case delta < 0:
+		return fmt.Sprintf("%s (%s ago)", t.Format(time.RFC3339), -delta)
+	default:
+		return fmt.Sprintf("%s (now)", t.Format(time.RFC3339))
+	}
+}
@@ -0,0 +1,259 @@
+// Copyright (C) 2022 Mya Pitzeruse
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pass
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// agentSocket is the name of the socket the agent listens on within the runtime directory.
+	agentSocket = "pass-agent.sock"
+	// agentDialTimeout bounds how long clients wait on an unresponsive agent before falling back to the passphrase.
+	agentDialTimeout = time.Second
+)
+
+// agentRequest asks the agent for the identity key of a user.
+type agentRequest struct {
+	User string `json:"user"`
+}
+
+// agentResponse carries the identity key, or the reason it could not be provided.
+type agentResponse struct {
+	Identity []byte `json:"identity,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// agentSocketPath returns the location of the agent's socket. It lives in $XDG_RUNTIME_DIR when available, falling back
+// to a per-user directory in the system's temporary directory.
+func agentSocketPath() string {
+	dir := filepath.Join(os.TempDir(), fmt.Sprintf("em-%d", os.Getuid()))
+	if runtime := os.Getenv("XDG_RUNTIME_DIR"); runtime != "" {
+		dir = filepath.Join(runtime, "em")
+	}
+
+	return filepath.Join(dir, agentSocket)
+}
+
+// checkOwned verifies that path is owned by the current user and is not a symlink. The fallback directory lives in a
+// world writable location, so another user could have created it (or the socket within it) ahead of time.
+func checkOwned(path string, mode os.FileMode) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+
+	if info.Mode()&os.ModeType != mode&os.ModeType {
+		return fmt.Errorf("%s is not a %s", path, describeMode(mode))
+	}
+
+	owner, err := fileOwner(info)
+	if err != nil {
+		return err
+	}
+
+	if owner != os.Getuid() {
+		return fmt.Errorf("%s is owned by uid %d, not the current user", path, owner)
+	}
+
+	if mode.IsDir() && info.Mode().Perm() != mode.Perm() {
+		return fmt.Errorf("%s has mode %04o, expected %04o", path, info.Mode().Perm(), mode.Perm())
+	}
+
+	return nil
+}
+
+func describeMode(mode os.FileMode) string {
+	if mode.IsDir() {
+		return "directory"
+	}
+
+	return "socket"
+}
+
+// checkPeer verifies that the process on the other end of the connection runs as the current user.
+func checkPeer(conn net.Conn) error {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return fmt.Errorf("unexpected connection type %T", conn)
+	}
+
+	uid, err := peerUID(unixConn)
+	if err != nil {
+		return err
+	}
+
+	if uid != os.Getuid() {
+		return fmt.Errorf("peer is running as uid %d, not the current user", uid)
+	}
+
+	return nil
+}
+
+// requestIdentity asks a running agent for the identity key of user. Nil is returned when no agent is running, it
+// holds the key for a different user, or the socket cannot be trusted.
+func requestIdentity(user string) []byte {
+	path := agentSocketPath()
+
+	if checkOwned(filepath.Dir(path), os.ModeDir|0o700) != nil || checkOwned(path, os.ModeSocket) != nil {
+		return nil
+	}
+
+	conn, err := net.DialTimeout("unix", path, agentDialTimeout)
+	if err != nil {
+		return nil
+	}
+
+	defer conn.Close()
+
+	if checkPeer(conn) != nil {
+		return nil
+	}
+
+	_ = conn.SetDeadline(time.Now().Add(agentDialTimeout))
+
+	if err = json.NewEncoder(conn).Encode(agentRequest{User: user}); err != nil {
+		return nil
+	}
+
+	resp := agentResponse{}
+	if err = json.NewDecoder(bufio.NewReader(conn)).Decode(&resp); err != nil || resp.Error != "" {
+		return nil
+	}
+
+	return resp.Identity
+}
+
+// agent holds an identity key in memory and hands it to em processes run by the same user.
+type agent struct {
+	user     string
+	identity []byte
+	timeout  time.Duration
+	activity chan struct{}
+}
+
+// listenAgent creates the agent socket, replacing a stale one left behind by an agent that did not shut down cleanly.
+// The directory holding the socket must belong to the current user and be inaccessible to everyone else.
+func listenAgent(path string) (net.Listener, error) {
+	dir := filepath.Dir(path)
+	if err := os.Mkdir(dir, 0o700); err != nil && !errors.Is(err, os.ErrExist) {
+		return nil, err
+	}
+
+	if err := checkOwned(dir, os.ModeDir|0o700); err != nil {
+		return nil, err
+	}
+
+	if conn, err := net.DialTimeout("unix", path, agentDialTimeout); err == nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("an agent is already listening on %s", path)
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	// only the owner may talk to the agent, the same protection ssh-agent relies on
+	if err = os.Chmod(path, 0o600); err != nil {
+		_ = listener.Close()
+		return nil, err
+	}
+
+	return listener, nil
+}
+
+// serve answers requests until the context is cancelled or no request arrives within the idle timeout. The identity
+// key is wiped before returning.
+func (a *agent) serve(ctx context.Context, listener net.Listener) error {
+	defer func() {
+		for i := range a.identity {
+			a.identity[i] = 0
+		}
+	}()
+
+	defer listener.Close()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go a.handle(conn)
+		}
+	}()
+
+	idle := time.NewTimer(a.timeout)
+	defer idle.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-idle.C:
+			return nil
+		case <-a.activity:
+			if !idle.Stop() {
+				<-idle.C
+			}
+
+			idle.Reset(a.timeout)
+		}
+	}
+}
+
+func (a *agent) handle(conn net.Conn) {
+	defer conn.Close()
+
+	if checkPeer(conn) != nil {
+		return
+	}
+
+	_ = conn.SetDeadline(time.Now().Add(agentDialTimeout))
+
+	req := agentRequest{}
+	if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&req); err != nil {
+		return
+	}
+
+	resp := agentResponse{}
+	if req.User == a.user {
+		resp.Identity = a.identity
+
+		select {
+		case a.activity <- struct{}{}:
+		default:
+		}
+	} else {
+		resp.Error = "the agent holds the identity of a different user"
+	}
+
+	_ = json.NewEncoder(conn).Encode(resp)
+}
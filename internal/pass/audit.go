@@ -0,0 +1,189 @@
+// Copyright (C) 2022 Mya Pitzeruse
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pass
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/nbutton23/zxcvbn-go"
+	"github.com/urfave/cli/v2"
+)
+
+// auditTemplates prints the guaranteed entropy of every template, along with the template each site policy selects.
+func auditTemplates(ctx *cli.Context, config *Config) error {
+	names := make([]string, 0, len(builtinPatterns)+len(extendedTemplates)+len(config.Templates))
+	for name := range builtinPatterns {
+		names = append(names, name)
+	}
+
+	for name := range extendedTemplates {
+		names = append(names, name)
+	}
+
+	for name := range config.Templates {
+		names = append(names, name)
+	}
+
+	entropy := make(map[string]float64, len(names))
+	for _, name := range names {
+		patterns, _ := config.Patterns(name)
+		entropy[name] = Policy{}.strength(patterns)
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		return entropy[names[i]] > entropy[names[j]]
+	})
+
+	writer := tabwriter.NewWriter(ctx.App.Writer, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "TEMPLATE\tBITS\tLENGTH\tSOURCE")
+
+	for _, name := range names {
+		patterns, _ := config.Patterns(name)
+
+		source := "builtin"
+		if _, ok := config.Templates[name]; ok {
+			source = "config"
+		}
+
+		_, _ = fmt.Fprintf(writer, "%s\t%.1f\t%s\t%s\n", name, entropy[name], lengths(patterns), source)
+	}
+
+	if len(config.Policies) > 0 {
+		sites := make([]string, 0, len(config.Policies))
+		for site := range config.Policies {
+			sites = append(sites, site)
+		}
+
+		sort.Strings(sites)
+
+		_, _ = fmt.Fprintln(writer)
+		_, _ = fmt.Fprintln(writer, "SITE\tTEMPLATE\tBITS")
+
+		for _, site := range sites {
+			policy := config.Policies[site]
+
+			name, err := config.Choose(policy)
+			if err != nil {
+				_, _ = fmt.Fprintf(writer, "%s\t%s\t-\n", site, "none satisfies the policy")
+				continue
+			}
+
+			patterns, _ := config.Patterns(name)
+			_, _ = fmt.Fprintf(writer, "%s\t%s\t%.1f\n", site, name, policy.strength(patterns))
+		}
+	}
+
+	return writer.Flush()
+}
+
+// lengths describes the range of lengths produced by the patterns.
+func lengths(patterns []string) string {
+	shortest, longest := len(patterns[0]), len(patterns[0])
+	for _, pattern := range patterns[1:] {
+		if len(pattern) < shortest {
+			shortest = len(pattern)
+		}
+
+		if len(pattern) > longest {
+			longest = len(pattern)
+		}
+	}
+
+	if shortest == longest {
+		return fmt.Sprint(shortest)
+	}
+
+	return fmt.Sprintf("%d-%d", shortest, longest)
+}
+
+// auditPasswords estimates the strength of each password read from reader, one per line, using zxcvbn's pattern
+// matching. Passwords are identified by line number so they are never echoed back. When a breach file is provided,
+// each password is also looked up in it and any hits cause the audit to fail.
+func auditPasswords(ctx *cli.Context, reader io.Reader, breachFile string) error {
+	var breaches *Breaches
+
+	if breachFile != "" {
+		var err error
+		if breaches, err = OpenBreaches(breachFile); err != nil {
+			return err
+		}
+
+		defer breaches.Close()
+	}
+
+	writer := tabwriter.NewWriter(ctx.App.Writer, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "LINE\tBITS\tSCORE\tCRACK TIME\tPATTERNS\tBREACHED")
+
+	total, breached := 0, 0
+
+	scanner := bufio.NewScanner(reader)
+	for line := 1; scanner.Scan(); line++ {
+		password := strings.TrimRight(scanner.Text(), "\r")
+		if password == "" {
+			continue
+		}
+
+		total++
+
+		result := zxcvbn.PasswordStrength(password, nil)
+
+		var patterns []string
+		seen := make(map[string]bool)
+
+		for _, match := range result.MatchSequence {
+			if !seen[match.Pattern] {
+				seen[match.Pattern] = true
+				patterns = append(patterns, match.Pattern)
+			}
+		}
+
+		status := "-"
+		if breaches != nil {
+			count, err := breaches.Count(password)
+			if err != nil {
+				return err
+			}
+
+			status = "no"
+			if count > 0 {
+				breached++
+				status = fmt.Sprintf("yes (%d times)", count)
+			}
+		}
+
+		_, _ = fmt.Fprintf(writer, "%d\t%.1f\t%d/4\t%s\t%s\t%s\n",
+			line, result.Entropy, result.Score, result.CrackTimeDisplay, strings.Join(patterns, "+"), status)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	if breached > 0 {
+		return fmt.Errorf("%d of %d passwords appear in %s", breached, total, breachFile)
+	}
+
+	return nil
+}
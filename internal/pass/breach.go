@@ -0,0 +1,143 @@
+// Copyright (C) 2022 Mya Pitzeruse
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pass
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// hibpLineLimit bounds the length of a line in a breach file. Lines hold a 40 character hash, a colon, and a count.
+const hibpLineLimit = 128
+
+// Breaches searches a locally stored Have I Been Pwned password file. The file must contain SHA-1 hashes ordered by
+// hash, one "HASH:COUNT" entry per line, as produced by the official downloader. Lookups use a binary search over the
+// file, so even the full list is searched without loading it into memory.
+type Breaches struct {
+	file *os.File
+	size int64
+}
+
+// OpenBreaches opens the breach file at path.
+func OpenBreaches(path string) (*Breaches, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	return &Breaches{file: file, size: info.Size()}, nil
+}
+
+// Close closes the underlying file.
+func (b *Breaches) Close() error {
+	return b.file.Close()
+}
+
+// Count returns the number of times the password appears in the breach file, or zero when it does not.
+func (b *Breaches) Count(password string) (int, error) {
+	digest := sha1.Sum([]byte(password))
+	target := bytes.ToUpper([]byte(hex.EncodeToString(digest[:])))
+
+	// the matching line, if present, always starts within [lo, hi) and lo is always the start of a line
+	lo, hi := int64(0), b.size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+
+		start, line, err := b.lineFrom(mid)
+		if err != nil {
+			return 0, err
+		}
+
+		if line == nil || start >= hi {
+			hi = mid
+			continue
+		}
+
+		hash, count, _ := bytes.Cut(bytes.TrimRight(line, "\r"), []byte(":"))
+
+		switch c := bytes.Compare(bytes.ToUpper(hash), target); {
+		case c == 0:
+			n, err := strconv.Atoi(string(count))
+			if err != nil {
+				return 0, fmt.Errorf("invalid count in breach file: %q", line)
+			}
+
+			return n, nil
+		case c < 0:
+			lo = start + int64(len(line)) + 1
+		default:
+			hi = mid
+		}
+	}
+
+	return 0, nil
+}
+
+// lineFrom returns the first line that starts at or after offset, without its newline. A nil line is returned when
+// there are no more lines.
+func (b *Breaches) lineFrom(offset int64) (int64, []byte, error) {
+	start := offset
+	if offset > 0 {
+		// read from the previous byte to find out whether offset begins a line
+		start = offset - 1
+	}
+
+	buf := make([]byte, 2*hibpLineLimit+2)
+
+	n, err := b.file.ReadAt(buf, start)
+	if err != nil && err != io.EOF {
+		return 0, nil, err
+	}
+
+	buf = buf[:n]
+
+	if offset > 0 {
+		idx := bytes.IndexByte(buf, '\n')
+		if idx < 0 {
+			if start+int64(len(buf)) < b.size {
+				return 0, nil, fmt.Errorf("breach file line near offset %d is longer than %d bytes", start, hibpLineLimit)
+			}
+
+			return 0, nil, nil
+		}
+
+		start += int64(idx) + 1
+		buf = buf[idx+1:]
+	}
+
+	if len(buf) == 0 {
+		return 0, nil, nil
+	}
+
+	if idx := bytes.IndexByte(buf, '\n'); idx >= 0 {
+		buf = buf[:idx]
+	} else if start+int64(len(buf)) < b.size {
+		return 0, nil, fmt.Errorf("breach file line at offset %d is longer than %d bytes", start, hibpLineLimit)
+	}
+
+	return start, buf, nil
+}
@@ -1,30 +1,80 @@
 package pass
 
 import (
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/base64"
+	"crypto/subtle"
 	"encoding/json"
 	"fmt"
+	"github.com/gofrs/flock"
 	"github.com/urfave/cli/v2"
+	"go.pitz.tech/em/internal/pass/wordlist"
 	"go.pitz.tech/lib/dirset"
 	"go.pitz.tech/lib/flagset"
 	"go.pitz.tech/lib/pass"
 	"os"
 	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
 )
 
 type DeriveConfig struct {
-	Passphrase string `json:"passphrase" usage:"the root passphrase all subsequent passphrases are derived from"`
-	User       string `json:"user" usage:"the name of the individual authenticating with the remote system"`
-	Site       string `json:"site" usage:"specify which site we're deriving a password for"`
-	Template   string `json:"template" usage:"which template pattern to use [max,long,medium,short,basic,pin,code]" default:"max"`
+	Passphrase              string `json:"passphrase" usage:"the root passphrase all subsequent passphrases are derived from (prefer the prompt, --passphrase_fd, or EM_PASSPHRASE)"`
+	PassphraseFD            int    `json:"passphrase_fd" usage:"read the root passphrase from the given file descriptor" default:"-1"`
+	AllowInsecurePassphrase bool   `json:"allow_insecure_passphrase" usage:"allow the root passphrase to be passed using --passphrase"`
+	Identicon               string `json:"identicon" usage:"show a fingerprint of the passphrase to catch typos [auto,always,never]" default:"auto"`
+	User                    string `json:"user" usage:"the name of the individual authenticating with the remote system"`
+	Site                    string `json:"site" usage:"specify which site we're deriving a password for"`
+	Template                string `json:"template" usage:"which template pattern to use [max,long,medium,short,basic,pin,code,name,phrase] or one from the config file, remembered for the site's password" default:"max"`
+	Notes                   string `json:"notes" usage:"notes to store alongside the site in the vault"`
+	Purpose                 string `json:"purpose" usage:"what to derive [password,login,recovery]" default:"password"`
+	Context                 string `json:"context" usage:"distinguishes recovery answers for different questions on the same site"`
+	Config                  string `json:"config" usage:"the file custom templates and site policies are read from (defaults to pass.yaml in the config directory)"`
 }
 
 type RotateConfig struct {
-	Passphrase string `json:"passphrase" usage:"the root passphrase all subsequent passphrases are derived from"`
-	User       string `json:"user" usage:"the name of the individual authenticating with the remote system"`
-	Site       string `json:"site" usage:"specify which site we're deriving a password for"`
+	Passphrase              string `json:"passphrase" usage:"the root passphrase all subsequent passphrases are derived from (prefer the prompt, --passphrase_fd, or EM_PASSPHRASE)"`
+	PassphraseFD            int    `json:"passphrase_fd" usage:"read the root passphrase from the given file descriptor" default:"-1"`
+	AllowInsecurePassphrase bool   `json:"allow_insecure_passphrase" usage:"allow the root passphrase to be passed using --passphrase"`
+	Identicon               string `json:"identicon" usage:"show a fingerprint of the passphrase to catch typos [auto,always,never]" default:"auto"`
+	User                    string `json:"user" usage:"the name of the individual authenticating with the remote system"`
+	Site                    string `json:"site" usage:"specify which site we're deriving a password for"`
+	To                      int    `json:"to" usage:"move the site to a specific counter instead of the next one"`
+	Undo                    bool   `json:"undo" usage:"return the site to the counter it used before the last rotation"`
+}
+
+type VaultConfig struct {
+	Passphrase              string `json:"passphrase" usage:"the root passphrase all subsequent passphrases are derived from (prefer the prompt, --passphrase_fd, or EM_PASSPHRASE)"`
+	PassphraseFD            int    `json:"passphrase_fd" usage:"read the root passphrase from the given file descriptor" default:"-1"`
+	AllowInsecurePassphrase bool   `json:"allow_insecure_passphrase" usage:"allow the root passphrase to be passed using --passphrase"`
+	Identicon               string `json:"identicon" usage:"show a fingerprint of the passphrase to catch typos [auto,always,never]" default:"auto"`
+	User                    string `json:"user" usage:"the name of the individual authenticating with the remote system"`
+}
+
+type PhraseConfig struct {
+	Passphrase              string `json:"passphrase" usage:"the root passphrase all subsequent passphrases are derived from (prefer the prompt, --passphrase_fd, or EM_PASSPHRASE)"`
+	PassphraseFD            int    `json:"passphrase_fd" usage:"read the root passphrase from the given file descriptor" default:"-1"`
+	AllowInsecurePassphrase bool   `json:"allow_insecure_passphrase" usage:"allow the root passphrase to be passed using --passphrase"`
+	Identicon               string `json:"identicon" usage:"show a fingerprint of the passphrase to catch typos [auto,always,never]" default:"auto"`
+	User                    string `json:"user" usage:"the name of the individual authenticating with the remote system"`
+	Site                    string `json:"site" usage:"derive the phrase for the given site instead of generating a random one"`
+	Words                   int    `json:"words" usage:"the number of words in the phrase" default:"6"`
+	Separator               string `json:"separator" usage:"the separator placed between words" default:"-"`
+	Wordlist                string `json:"wordlist" usage:"the wordlist words are chosen from [eff-large,eff-short]" default:"eff-large"`
+}
+
+type AuditConfig struct {
+	Config string `json:"config" usage:"the file custom templates and site policies are read from (defaults to pass.yaml in the config directory)"`
+	Stdin  bool   `json:"stdin" usage:"audit passwords read from stdin, one per line, instead of templates"`
+	HIBP   string `json:"hibp" usage:"path to a downloaded Have I Been Pwned SHA-1 password file, ordered by hash"`
+}
+
+type AgentConfig struct {
+	Passphrase              string        `json:"passphrase" usage:"the root passphrase all subsequent passphrases are derived from (prefer the prompt, --passphrase_fd, or EM_PASSPHRASE)"`
+	PassphraseFD            int           `json:"passphrase_fd" usage:"read the root passphrase from the given file descriptor" default:"-1"`
+	AllowInsecurePassphrase bool          `json:"allow_insecure_passphrase" usage:"allow the root passphrase to be passed using --passphrase"`
+	Identicon               string        `json:"identicon" usage:"show a fingerprint of the passphrase to catch typos [auto,always,never]" default:"auto"`
+	User                    string        `json:"user" usage:"the name of the individual authenticating with the remote system"`
+	Timeout                 time.Duration `json:"timeout" usage:"how long the agent waits without a request before exiting" default:"15m"`
 }
 
 type PurgeConfig struct {
@@ -32,9 +82,15 @@ type PurgeConfig struct {
 }
 
 var (
-	deriveConfig = &DeriveConfig{}
-	rotateConfig = &RotateConfig{}
-	purgeConfig  = &PurgeConfig{}
+	deriveConfig  = &DeriveConfig{}
+	rotateConfig  = &RotateConfig{}
+	listConfig    = &VaultConfig{}
+	showConfig    = &VaultConfig{}
+	historyConfig = &VaultConfig{}
+	phraseConfig  = &PhraseConfig{}
+	auditConfig   = &AuditConfig{}
+	agentConfig   = &AgentConfig{}
+	purgeConfig   = &PurgeConfig{}
 
 	templates = map[string]pass.TemplateClass{
 		string(pass.MaximumSecurity):  pass.MaximumSecurity,
@@ -59,35 +115,112 @@ var (
 				Action: func(ctx *cli.Context) error {
 					cfg := deriveConfig
 
-					dirset := dirset.Must(ctx.App.Name)
-					err := os.MkdirAll(dirset.StateDir, 0755)
+					purpose, ok := purposes[cfg.Purpose]
+					if !ok {
+						return fmt.Errorf("unrecognized purpose: %s", cfg.Purpose)
+					}
+
+					if cfg.Context != "" && purpose.scope != pass.Recovery {
+						return fmt.Errorf("--context is only supported with --purpose recovery")
+					}
+
+					template := cfg.Template
+					if purpose.scope != pass.Authentication && !ctx.IsSet("template") {
+						template = purpose.template
+					}
+
+					config, err := loadConfig(ctx, cfg.Config)
 					if err != nil {
 						return err
 					}
 
-					generations, err := loadGenerations(dirset.StateDir)
+					if _, ok := config.Patterns(template); !ok {
+						return fmt.Errorf("unrecognized template: %s", template)
+					}
+
+					session, err := unlock(ctx, PassphraseSource{
+						Flag:      cfg.Passphrase,
+						AllowFlag: cfg.AllowInsecurePassphrase,
+						FD:        cfg.PassphraseFD,
+					}, cfg.User, cfg.Identicon)
 					if err != nil {
 						return err
 					}
 
-					template, ok := templates[cfg.Template]
-					if !ok {
-						return fmt.Errorf("unrecognized template: %s", cfg.Template)
-					}
+					defer session.Close()
 
-					key := hashKey(cfg.Passphrase, cfg.User, cfg.Site)
-					generation, ok := generations[key]
-					if !ok {
-						generation = 1
+					if purpose.scope != pass.Authentication {
+						if cfg.Site == "" {
+							return fmt.Errorf("a site is required")
+						}
+
+						// login names and answers do not change when the password is rotated, so they always use
+						// the first counter and are never recorded in the vault
+						value, err := config.Password(siteKey(purpose.scope, session.identity, cfg.Site, 1, cfg.Context), template, nil)
+						if err != nil {
+							return err
+						}
+
+						_, err = ctx.App.Writer.Write(value)
+
+						return err
 					}
 
-					identity, err := pass.Identity(pass.Authentication, []byte(cfg.Passphrase), cfg.User)
+					site, err := session.lookup(cfg.Site, cfg.Template)
 					if err != nil {
 						return err
 					}
 
-					siteKey := pass.SiteKey(pass.Authentication, identity, cfg.Site, generation)
-					password := pass.SitePassword(siteKey, template)
+					changed := false
+					if site == nil {
+						site = &Site{Name: cfg.Site, User: cfg.User, Template: cfg.Template, Counter: 1}
+						changed = true
+					}
+
+					if ctx.IsSet("template") && site.Template != cfg.Template {
+						site.Template = cfg.Template
+						changed = true
+					}
+
+					// a site's policy takes precedence over its remembered template, but an explicitly requested
+					// template that breaks the policy is reported rather than silently replaced
+					policy, hasPolicy := config.Policies[site.Name]
+					if hasPolicy {
+						if err := config.Check(site.Template, policy); err != nil {
+							if ctx.IsSet("template") {
+								return fmt.Errorf("template %s does not satisfy the policy for %s: %w", site.Template, site.Name, err)
+							}
+
+							if site.Template, err = config.Choose(policy); err != nil {
+								return fmt.Errorf("%s: %w", site.Name, err)
+							}
+
+							changed = true
+						}
+					}
+
+					if ctx.IsSet("notes") && site.Notes != cfg.Notes {
+						site.Notes = cfg.Notes
+						changed = true
+					}
+
+					if changed {
+						session.vault.Put(site)
+
+						if err = session.vault.Save(); err != nil {
+							return err
+						}
+					}
+
+					var sitePolicy *Policy
+					if hasPolicy {
+						sitePolicy = &policy
+					}
+
+					password, err := config.Password(siteKey(pass.Authentication, session.identity, site.Name, site.Counter, ""), site.Template, sitePolicy)
+					if err != nil {
+						return err
+					}
 
 					_, err = ctx.App.Writer.Write(password)
 
@@ -102,35 +235,312 @@ var (
 				Action: func(ctx *cli.Context) error {
 					cfg := rotateConfig
 
-					dirset := dirset.Must(ctx.App.Name)
-					err := os.MkdirAll(dirset.StateDir, 0755)
+					switch {
+					case cfg.To < 0:
+						return fmt.Errorf("--to must be a positive counter")
+					case cfg.To > 0 && cfg.Undo:
+						return fmt.Errorf("--to and --undo cannot be used together")
+					}
+
+					session, err := unlock(ctx, PassphraseSource{
+						Flag:      cfg.Passphrase,
+						AllowFlag: cfg.AllowInsecurePassphrase,
+						FD:        cfg.PassphraseFD,
+					}, cfg.User, cfg.Identicon)
 					if err != nil {
 						return err
 					}
 
-					generations, err := loadGenerations(dirset.StateDir)
+					defer session.Close()
+
+					site, err := session.lookup(cfg.Site, string(pass.MaximumSecurity))
 					if err != nil {
 						return err
 					}
 
-					key := hashKey(cfg.Passphrase, cfg.User, cfg.Site)
+					if site == nil {
+						site = &Site{Name: cfg.Site, User: cfg.User, Template: string(pass.MaximumSecurity), Counter: 1}
+					}
 
-					if _, ok := generations[key]; !ok {
-						generations[key] = 1
+					previous := site.Counter
+
+					switch {
+					case cfg.Undo:
+						if err = site.Undo(); err != nil {
+							return err
+						}
+					case cfg.To > 0:
+						if uint32(cfg.To) == site.Counter {
+							return fmt.Errorf("%s is already using counter %d", site.Name, site.Counter)
+						}
+
+						site.SetCounter(uint32(cfg.To))
+					default:
+						site.SetCounter(site.Counter + 1)
 					}
 
-					generations[key]++
+					session.vault.Put(site)
 
-					err = writeGenerations(dirset.StateDir, generations)
+					err = session.vault.Save()
 					if err != nil {
 						return err
 					}
 
-					ctx.App.Writer.Write([]byte("rotated!\n"))
+					_, err = fmt.Fprintf(ctx.App.Writer, "%s: counter %d -> %d\n", site.Name, previous, site.Counter)
+
+					return err
+				},
+			},
+			{
+				Name:            "list",
+				Usage:           "List the sites stored in the vault.",
+				Flags:           flagset.ExtractPrefix("em", listConfig),
+				HideHelpCommand: true,
+				Action: func(ctx *cli.Context) error {
+					cfg := listConfig
+
+					session, err := unlock(ctx, PassphraseSource{
+						Flag:      cfg.Passphrase,
+						AllowFlag: cfg.AllowInsecurePassphrase,
+						FD:        cfg.PassphraseFD,
+					}, cfg.User, cfg.Identicon)
+					if err != nil {
+						return err
+					}
+
+					defer session.Close()
+
+					writer := tabwriter.NewWriter(ctx.App.Writer, 0, 4, 2, ' ', 0)
+					_, _ = fmt.Fprintln(writer, "SITE\tUSER\tTEMPLATE\tCOUNTER\tUPDATED")
+
+					for _, site := range session.vault.List() {
+						_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t%d\t%s\n",
+							site.Name, site.User, site.Template, site.Counter, site.UpdatedAt.Local().Format(time.RFC3339))
+					}
+
+					if err = writer.Flush(); err != nil {
+						return err
+					}
+
+					remaining, err := unmigrated(session.directory)
+					if err != nil {
+						return err
+					}
+
+					if remaining > 0 {
+						_, _ = fmt.Fprintf(ctx.App.ErrWriter, "unlisted entries remaining in %s: %d (each moves into the "+
+							"vault the next time its site is derived or shown)\n", legacyFile, remaining)
+					}
 
 					return nil
 				},
 			},
+			{
+				Name:            "show",
+				Usage:           "Show the vault entry for a site.",
+				UsageText:       "em pass show [options] <site>",
+				Flags:           flagset.ExtractPrefix("em", showConfig),
+				HideHelpCommand: true,
+				Action: func(ctx *cli.Context) error {
+					cfg := showConfig
+
+					if ctx.NArg() != 1 {
+						return fmt.Errorf("expected a single site")
+					}
+
+					session, err := unlock(ctx, PassphraseSource{
+						Flag:      cfg.Passphrase,
+						AllowFlag: cfg.AllowInsecurePassphrase,
+						FD:        cfg.PassphraseFD,
+					}, cfg.User, cfg.Identicon)
+					if err != nil {
+						return err
+					}
+
+					defer session.Close()
+
+					site, err := session.lookup(ctx.Args().Get(0), string(pass.MaximumSecurity))
+					if err != nil {
+						return err
+					}
+
+					if site == nil {
+						return fmt.Errorf("no vault entry for site: %s", ctx.Args().Get(0))
+					}
+
+					enc := json.NewEncoder(ctx.App.Writer)
+					enc.SetIndent("", "  ")
+
+					return enc.Encode(site)
+				},
+			},
+			{
+				Name:            "history",
+				Usage:           "List the counters a site has used.",
+				UsageText:       "em pass history [options] <site>",
+				Flags:           flagset.ExtractPrefix("em", historyConfig),
+				HideHelpCommand: true,
+				Action: func(ctx *cli.Context) error {
+					cfg := historyConfig
+
+					if ctx.NArg() != 1 {
+						return fmt.Errorf("expected a single site")
+					}
+
+					session, err := unlock(ctx, PassphraseSource{
+						Flag:      cfg.Passphrase,
+						AllowFlag: cfg.AllowInsecurePassphrase,
+						FD:        cfg.PassphraseFD,
+					}, cfg.User, cfg.Identicon)
+					if err != nil {
+						return err
+					}
+
+					defer session.Close()
+
+					site, err := session.lookup(ctx.Args().Get(0), string(pass.MaximumSecurity))
+					if err != nil {
+						return err
+					}
+
+					if site == nil {
+						return fmt.Errorf("no vault entry for site: %s", ctx.Args().Get(0))
+					}
+
+					site.seedHistory()
+
+					writer := tabwriter.NewWriter(ctx.App.Writer, 0, 4, 2, ' ', 0)
+					_, _ = fmt.Fprintln(writer, "COUNTER\tSINCE")
+
+					for i, generation := range site.History {
+						current := ""
+						if i == len(site.History)-1 {
+							current = " (current)"
+						}
+
+						_, _ = fmt.Fprintf(writer, "%d\t%s%s\n", generation.Counter, generation.At.Local().Format(time.RFC3339), current)
+					}
+
+					return writer.Flush()
+				},
+			},
+			{
+				Name:            "phrase",
+				Usage:           "Generate a diceware passphrase, either at random or derived for a site.",
+				Flags:           flagset.ExtractPrefix("em", phraseConfig),
+				HideHelpCommand: true,
+				Action: func(ctx *cli.Context) error {
+					cfg := phraseConfig
+
+					words, ok := wordlist.Lookup(cfg.Wordlist)
+					if !ok {
+						return fmt.Errorf("unrecognized wordlist: %s (use %s)", cfg.Wordlist, strings.Join(wordlist.Names(), ", "))
+					}
+
+					var phrase string
+					var err error
+
+					if cfg.Site == "" {
+						phrase, err = randomPhrase(words, cfg.Words, cfg.Separator)
+					} else {
+						phrase, err = sitePhrase(ctx, cfg, words)
+					}
+
+					if err != nil {
+						return err
+					}
+
+					_, _ = fmt.Fprintf(ctx.App.ErrWriter, "entropy: %.1f bits (%d words from %s)\n",
+						phraseEntropy(len(words), cfg.Words), cfg.Words, cfg.Wordlist)
+
+					_, err = ctx.App.Writer.Write([]byte(phrase))
+
+					return err
+				},
+			},
+			{
+				Name:  "audit",
+				Usage: "Estimate the strength of templates, or of passwords read from stdin.",
+				UsageText: strings.Join([]string{
+					"em pass audit [--config pass.yaml]",
+					"em pass audit --stdin [--hibp pwned-passwords-sha1-ordered-by-hash.txt] < passwords.txt",
+				}, "\n"),
+				Flags:           flagset.ExtractPrefix("em", auditConfig),
+				HideHelpCommand: true,
+				Action: func(ctx *cli.Context) error {
+					cfg := auditConfig
+
+					if cfg.Stdin {
+						return auditPasswords(ctx, ctx.App.Reader, cfg.HIBP)
+					}
+
+					config, err := loadConfig(ctx, cfg.Config)
+					if err != nil {
+						return err
+					}
+
+					return auditTemplates(ctx, config)
+				},
+			},
+			{
+				Name:            "agent",
+				Usage:           "Hold the identity key in memory so other pass commands skip the passphrase.",
+				Flags:           flagset.ExtractPrefix("em", agentConfig),
+				HideHelpCommand: true,
+				Action: func(ctx *cli.Context) error {
+					cfg := agentConfig
+
+					if cfg.User == "" {
+						return fmt.Errorf("--user is required")
+					}
+
+					if cfg.Timeout <= 0 {
+						return fmt.Errorf("--timeout must be positive")
+					}
+
+					passphrase, err := readPassphrase(ctx, PassphraseSource{
+						Flag:      cfg.Passphrase,
+						AllowFlag: cfg.AllowInsecurePassphrase,
+						FD:        cfg.PassphraseFD,
+					})
+					if err != nil {
+						return err
+					}
+
+					identity, err := pass.Identity(pass.Authentication, []byte(passphrase), cfg.User)
+					if err != nil {
+						return err
+					}
+
+					if err = writeIdenticon(ctx, cfg.Identicon, cfg.User, identity); err != nil {
+						return err
+					}
+
+					if err = lockMemory(identity); err != nil {
+						_, _ = fmt.Fprintf(ctx.App.ErrWriter, "warning: the identity key may be swapped to disk: %v\n", err)
+					} else {
+						defer unlockMemory(identity)
+					}
+
+					path := agentSocketPath()
+
+					listener, err := listenAgent(path)
+					if err != nil {
+						return err
+					}
+
+					_, _ = fmt.Fprintf(ctx.App.ErrWriter, "agent listening on %s, exiting after %s without requests\n", path, cfg.Timeout)
+
+					agent := &agent{
+						user:     cfg.User,
+						identity: identity,
+						timeout:  cfg.Timeout,
+						activity: make(chan struct{}, 1),
+					}
+
+					return agent.serve(ctx.Context, listener)
+				},
+			},
 			{
 				Name:            "purge",
 				Usage:           "Purges the local database.",
@@ -144,7 +554,29 @@ var (
 					}
 
 					dirset := dirset.Must(ctx.App.Name)
-					_ = os.Remove(filepath.Join(dirset.StateDir, "pass-generations.json"))
+
+					// nothing has been stored yet, so there's nothing to lock or purge
+					if _, err := os.Stat(dirset.StateDir); os.IsNotExist(err) {
+						return nil
+					}
+
+					lock, err := lockState(ctx.Context, dirset.StateDir)
+					if err != nil {
+						return err
+					}
+
+					defer lock.Unlock()
+
+					// backups are purged along with the state they were taken from
+					files, _ := filepath.Glob(filepath.Join(dirset.StateDir, vaultPrefix+"*"))
+					files = append(files,
+						filepath.Join(dirset.StateDir, legacyFile),
+						filepath.Join(dirset.StateDir, legacyFile+backupSuffix),
+					)
+
+					for _, file := range files {
+						_ = os.Remove(file)
+					}
 
 					return nil
 				},
@@ -153,40 +585,161 @@ var (
 	}
 )
 
-// hashKey computes a dictionary key using the passphrase as an hmac seed, and the user and site as the hash key.
-func hashKey(passphrase, user, site string) string {
-	hmacKey := sha256.Sum256([]byte(passphrase))
-	hmac := hmac.New(sha256.New, hmacKey[:])
+// session holds the unlocked state shared by commands that work with the vault.
+type session struct {
+	ctx        *cli.Context
+	source     PassphraseSource
+	lock       *flock.Flock
+	directory  string
+	passphrase string
+	user       string
+	identity   []byte
+	vault      *Vault
+}
 
-	_, _ = hmac.Write([]byte(user + "@" + site))
+// unlock reads the root passphrase, derives the identity for the user, locks the pass state, and opens the identity's
+// vault. The session must be closed to release the lock.
+func unlock(ctx *cli.Context, source PassphraseSource, user, identicon string) (*session, error) {
+	if user == "" {
+		return nil, fmt.Errorf("--user is required")
+	}
 
-	return base64.URLEncoding.EncodeToString(hmac.Sum(nil))
-}
+	if _, _, err := showIdenticon(ctx, identicon); err != nil {
+		return nil, err
+	}
+
+	dirset := dirset.Must(ctx.App.Name)
+	err := os.MkdirAll(dirset.StateDir, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	// a running agent saves both the prompt and the expensive identity derivation, unless the passphrase was
+	// provided explicitly for this command
+	var passphrase string
 
-func loadGenerations(directory string) (map[string]uint32, error) {
-	generations := make(map[string]uint32)
+	identity := []byte(nil)
+	if !source.explicit() {
+		identity = requestIdentity(user)
+	}
 
-	if handle, err := os.Open(filepath.Join(directory, "pass-generations.json")); err == nil {
-		defer handle.Close()
+	if identity == nil {
+		passphrase, err = readPassphrase(ctx, source)
+		if err != nil {
+			return nil, err
+		}
 
-		err = json.NewDecoder(handle).Decode(&generations)
+		identity, err = pass.Identity(pass.Authentication, []byte(passphrase), user)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	return generations, nil
+	if err = writeIdenticon(ctx, identicon, user, identity); err != nil {
+		return nil, err
+	}
+
+	lock, err := lockState(ctx.Context, dirset.StateDir)
+	if err != nil {
+		return nil, err
+	}
+
+	vault, err := OpenVault(dirset.StateDir, identity)
+	if err != nil {
+		_ = lock.Unlock()
+		return nil, err
+	}
+
+	return &session{
+		ctx:        ctx,
+		source:     source,
+		lock:       lock,
+		directory:  dirset.StateDir,
+		passphrase: passphrase,
+		user:       user,
+		identity:   identity,
+		vault:      vault,
+	}, nil
 }
 
-func writeGenerations(directory string, generations map[string]uint32) error {
-	handle, err := os.OpenFile(filepath.Join(directory, "pass-generations.json"), os.O_TRUNC|os.O_RDWR|os.O_CREATE, 0600)
+// Close releases the lock on the pass state.
+func (s *session) Close() error {
+	return s.lock.Unlock()
+}
+
+// lookup returns the vault entry for a site, migrating it from the legacy generations file when needed. Migrated
+// entries use the provided template since the legacy file never recorded one. Nil is returned for unknown sites.
+func (s *session) lookup(site, template string) (*Site, error) {
+	if site == "" {
+		return nil, fmt.Errorf("a site is required")
+	}
+
+	if entry, ok := s.vault.Sites[site]; ok {
+		return entry, nil
+	}
+
+	// legacy entries are keyed by the passphrase, which is unknown when the identity came from the agent. it's only
+	// asked for when there's a legacy entry the site could be stored under.
+	if s.passphrase == "" {
+		remaining, err := unmigrated(s.directory)
+		if err != nil || remaining == 0 {
+			return nil, err
+		}
+
+		_, _ = fmt.Fprintf(s.ctx.App.ErrWriter, "%s is not in the vault, the passphrase is needed to check the legacy generations file\n", site)
+
+		if err = s.readPassphrase(); err != nil {
+			return nil, err
+		}
+	}
+
+	return migrate(s.directory, s.vault, s.passphrase, s.user, site, template)
+}
+
+// readPassphrase reads the passphrase for a session unlocked by the agent, ensuring it matches the agent's identity.
+func (s *session) readPassphrase() error {
+	passphrase, err := readPassphrase(s.ctx, s.source)
 	if err != nil {
 		return err
 	}
 
-	defer handle.Close()
+	identity, err := pass.Identity(pass.Authentication, []byte(passphrase), s.user)
+	if err != nil {
+		return err
+	}
+
+	if subtle.ConstantTimeCompare(identity, s.identity) != 1 {
+		return fmt.Errorf("the passphrase does not match the identity held by the agent")
+	}
+
+	s.passphrase = passphrase
+
+	return nil
+}
+
+// sitePhrase derives the phrase for the configured site. Derived phrases follow the site's counter, so they change
+// when the site is rotated.
+func sitePhrase(ctx *cli.Context, cfg *PhraseConfig, words []string) (string, error) {
+	session, err := unlock(ctx, PassphraseSource{
+		Flag:      cfg.Passphrase,
+		AllowFlag: cfg.AllowInsecurePassphrase,
+		FD:        cfg.PassphraseFD,
+	}, cfg.User, cfg.Identicon)
+	if err != nil {
+		return "", err
+	}
 
-	err = json.NewEncoder(handle).Encode(generations)
+	defer session.Close()
+
+	site, err := session.lookup(cfg.Site, string(pass.MaximumSecurity))
+	if err != nil {
+		return "", err
+	}
+
+	counter := uint32(1)
+	if site != nil {
+		counter = site.Counter
+	}
 
-	return err
+	return derivedPhrase(siteKey(pass.Authentication, session.identity, cfg.Site, counter, ""), words, cfg.Words, cfg.Separator)
 }
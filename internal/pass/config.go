@@ -0,0 +1,345 @@
+// Copyright (C) 2022 Mya Pitzeruse
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pass
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+
+	"go.pitz.tech/lib/dirset"
+	"go.pitz.tech/lib/pass"
+)
+
+// configFile is the name of the pass configuration file within the config directory.
+const configFile = "pass.yaml"
+
+// Config holds user defined templates and per-site policies. For example:
+//
+//	templates:
+//	  hex: ["nnnnnnnnnnnnnnnn"]
+//	policies:
+//	  bank.example.com:
+//	    max_length: 12
+//	    require: [upper, digit]
+//	    forbid: "&'"
+type Config struct {
+	Templates map[string][]string `yaml:"templates"`
+	Policies  map[string]Policy   `yaml:"policies"`
+}
+
+// Policy describes the password rules imposed by a site.
+type Policy struct {
+	MinLength int      `yaml:"min_length"`
+	MaxLength int      `yaml:"max_length"`
+	Require   []string `yaml:"require"`
+	Forbid    string   `yaml:"forbid"`
+}
+
+// categories group characters for the require rule of a policy.
+var categories = map[string]func(b byte) bool{
+	"upper":  func(b byte) bool { return b >= 'A' && b <= 'Z' },
+	"lower":  func(b byte) bool { return b >= 'a' && b <= 'z' },
+	"digit":  func(b byte) bool { return b >= '0' && b <= '9' },
+	"symbol": func(b byte) bool { return b > ' ' && b <= '~' && !isAlphanumeric(b) },
+}
+
+func isAlphanumeric(b byte) bool {
+	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9')
+}
+
+// LoadConfig reads the pass configuration. A missing file is only an error when the path was given explicitly.
+func LoadConfig(path string, explicit bool) (*Config, error) {
+	cfg := &Config{}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist) && !explicit:
+		return cfg, nil
+	case err != nil:
+		return nil, err
+	}
+
+	if err = yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	for name, patterns := range cfg.Templates {
+		if _, ok := builtinPatterns[name]; ok {
+			return nil, fmt.Errorf("%s: template %s conflicts with a built-in template", path, name)
+		}
+
+		if _, ok := extendedTemplates[name]; ok {
+			return nil, fmt.Errorf("%s: template %s conflicts with a built-in template", path, name)
+		}
+
+		if len(patterns) == 0 {
+			return nil, fmt.Errorf("%s: template %s has no patterns", path, name)
+		}
+
+		for _, pattern := range patterns {
+			if pattern == "" {
+				return nil, fmt.Errorf("%s: template %s has an empty pattern", path, name)
+			}
+
+			if len(pattern) > maxPatternLength {
+				return nil, fmt.Errorf("%s: template %s has a pattern longer than %d characters", path, name, maxPatternLength)
+			}
+
+			for i := 0; i < len(pattern); i++ {
+				if _, ok := characterClasses[pattern[i]]; !ok {
+					return nil, fmt.Errorf("%s: template %s uses unknown character class %q", path, name, pattern[i])
+				}
+			}
+		}
+	}
+
+	for site, policy := range cfg.Policies {
+		for _, category := range policy.Require {
+			if _, ok := categories[category]; !ok {
+				return nil, fmt.Errorf("%s: policy for %s requires unknown category %s", path, site, category)
+			}
+		}
+	}
+
+	return cfg, nil
+}
+
+// loadConfig reads the configuration from path, or from pass.yaml in the config directory when path is empty.
+func loadConfig(ctx *cli.Context, path string) (*Config, error) {
+	if path != "" {
+		return LoadConfig(path, true)
+	}
+
+	return LoadConfig(filepath.Join(dirset.Must(ctx.App.Name).ConfigDir, configFile), false)
+}
+
+// Patterns returns the patterns behind a built-in, extended, or custom template.
+func (c *Config) Patterns(name string) ([]string, bool) {
+	if patterns, ok := builtinPatterns[name]; ok {
+		return patterns, true
+	}
+
+	if patterns, ok := extendedTemplates[name]; ok {
+		return patterns, true
+	}
+
+	patterns, ok := c.Templates[name]
+
+	return patterns, ok
+}
+
+// Password renders the site key using the named template. Built-in templates are always rendered by the pass library.
+// Other templates have the characters a policy forbids removed from every character class before rendering. Passwords
+// are verified against the policy before being returned.
+func (c *Config) Password(siteKey []byte, name string, policy *Policy) ([]byte, error) {
+	var password []byte
+
+	if class, ok := templates[name]; ok {
+		password = pass.SitePassword(siteKey, class)
+	} else {
+		patterns, ok := c.Patterns(name)
+		if !ok {
+			return nil, fmt.Errorf("unrecognized template: %s", name)
+		}
+
+		classes := characterClasses
+		if policy != nil {
+			classes = policy.classes()
+		}
+
+		password = render(siteKey, patterns, classes)
+	}
+
+	if policy != nil {
+		if err := policy.verify(password); err != nil {
+			return nil, fmt.Errorf("template %s produced a password that does not satisfy the policy: %w", name, err)
+		}
+	}
+
+	return password, nil
+}
+
+// Check reports why the named template does not satisfy the policy. The pass library renders built-in templates with
+// its own character classes, so they only satisfy a policy that forbids none of the characters they can produce.
+func (c *Config) Check(name string, policy Policy) error {
+	patterns, ok := c.Patterns(name)
+	if !ok {
+		return fmt.Errorf("unrecognized template: %s", name)
+	}
+
+	if _, ok := templates[name]; ok && policy.Forbid != "" {
+		for _, pattern := range patterns {
+			for i := 0; i < len(pattern); i++ {
+				if strings.ContainsAny(characterClasses[pattern[i]], policy.Forbid) {
+					return fmt.Errorf("may produce forbidden characters")
+				}
+			}
+		}
+	}
+
+	return policy.Check(patterns)
+}
+
+// Choose returns the strongest password template that satisfies the policy. Strength is the smallest number of bits
+// of entropy among a template's patterns. The name and phrase templates are never chosen since they are meant for
+// login names and security answers.
+func (c *Config) Choose(policy Policy) (string, error) {
+	names := make([]string, 0, len(builtinPatterns)+len(c.Templates))
+	for name := range builtinPatterns {
+		names = append(names, name)
+	}
+
+	for name := range c.Templates {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	best, bestBits := "", 0.0
+
+	for _, name := range names {
+		patterns, _ := c.Patterns(name)
+		if c.Check(name, policy) != nil {
+			continue
+		}
+
+		if bits := policy.strength(patterns); best == "" || bits > bestBits {
+			best, bestBits = name, bits
+		}
+	}
+
+	if best == "" {
+		return "", fmt.Errorf("no template satisfies the policy, define one in %s", configFile)
+	}
+
+	return best, nil
+}
+
+// classes returns the character classes with the forbidden characters removed.
+func (p Policy) classes() map[byte]string {
+	if p.Forbid == "" {
+		return characterClasses
+	}
+
+	classes := make(map[byte]string, len(characterClasses))
+	for key, class := range characterClasses {
+		classes[key] = strings.Map(func(r rune) rune {
+			if strings.ContainsRune(p.Forbid, r) {
+				return -1
+			}
+
+			return r
+		}, class)
+	}
+
+	return classes
+}
+
+// Check reports why the patterns do not satisfy the policy, or nil when every pattern does. A required category is
+// only satisfied when a pattern has a position that always produces a character from it.
+func (p Policy) Check(patterns []string) error {
+	classes := p.classes()
+
+	for _, pattern := range patterns {
+		switch {
+		case p.MinLength > 0 && len(pattern) < p.MinLength:
+			return fmt.Errorf("shorter than %d characters", p.MinLength)
+		case p.MaxLength > 0 && len(pattern) > p.MaxLength:
+			return fmt.Errorf("longer than %d characters", p.MaxLength)
+		}
+
+		for i := 0; i < len(pattern); i++ {
+			if classes[pattern[i]] == "" {
+				return fmt.Errorf("every character of class %q is forbidden", pattern[i])
+			}
+		}
+
+		for _, category := range p.Require {
+			if !guarantees(pattern, classes, categories[category]) {
+				return fmt.Errorf("does not always include a %s character", category)
+			}
+		}
+	}
+
+	return nil
+}
+
+// verify reports why a rendered password does not satisfy the policy.
+func (p Policy) verify(password []byte) error {
+	switch {
+	case p.MinLength > 0 && len(password) < p.MinLength:
+		return fmt.Errorf("shorter than %d characters", p.MinLength)
+	case p.MaxLength > 0 && len(password) > p.MaxLength:
+		return fmt.Errorf("longer than %d characters", p.MaxLength)
+	case p.Forbid != "" && strings.ContainsAny(string(password), p.Forbid):
+		return fmt.Errorf("includes a forbidden character")
+	}
+
+	for _, category := range p.Require {
+		found := false
+		for i := 0; i < len(password) && !found; i++ {
+			found = categories[category](password[i])
+		}
+
+		if !found {
+			return fmt.Errorf("does not include a %s character", category)
+		}
+	}
+
+	return nil
+}
+
+func guarantees(pattern string, classes map[byte]string, member func(b byte) bool) bool {
+	for i := 0; i < len(pattern); i++ {
+		class := classes[pattern[i]]
+
+		all := true
+		for j := 0; j < len(class) && all; j++ {
+			all = member(class[j])
+		}
+
+		if all {
+			return true
+		}
+	}
+
+	return false
+}
+
+// strength returns the smallest number of bits of entropy among the patterns.
+func (p Policy) strength(patterns []string) float64 {
+	classes := p.classes()
+	weakest := math.Inf(1)
+
+	for _, pattern := range patterns {
+		bits := 0.0
+		for i := 0; i < len(pattern); i++ {
+			bits += math.Log2(float64(len(classes[pattern[i]])))
+		}
+
+		weakest = math.Min(weakest, bits)
+	}
+
+	return weakest
+}
@@ -0,0 +1,92 @@
+// Copyright (C) 2022 Mya Pitzeruse
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pass
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/term"
+)
+
+// The identicon glyphs follow the layout popularized by Master Password: a left arm, a body, a right arm, and an
+// accessory, drawn in one of seven colors.
+var (
+	leftArms    = []string{"╔", "╚", "╰", "═"}
+	rightArms   = []string{"╗", "╝", "╯", "═"}
+	bodies      = []string{"█", "░", "▒", "▓", "☺", "☻"}
+	accessories = []string{
+		"◈", "◎", "◐", "◑", "◒", "◓", "☀", "☁", "☂", "☃", "☄", "★", "☆", "☎", "☏", "⎈", "⌂", "☘", "☢", "☣",
+		"☕", "⌚", "⌛", "⏰", "⚡", "⛄", "⛅", "☔", "♔", "♕", "♖", "♗", "♘", "♙", "♚", "♛", "♜", "♝", "♞", "♟",
+		"♨", "♩", "♪", "♫", "⚐", "⚑", "⚔", "⚖", "⚙", "⚠", "⌘", "⏎", "✄", "✆", "✈", "✉", "✌",
+	}
+)
+
+// Identicon derives a short glyph and an ANSI color (31 through 37) from an identity key. The same passphrase and user
+// always produce the same identicon, so a typo is noticed as soon as an unfamiliar one appears. Only a handful of bits
+// are exposed, which is not enough to help anyone guess the passphrase.
+func Identicon(identity []byte) (glyph string, color int) {
+	mac := hmac.New(sha256.New, identity)
+	_, _ = mac.Write([]byte("identicon"))
+	seed := mac.Sum(nil)
+
+	glyph = leftArms[int(seed[0])%len(leftArms)] +
+		bodies[int(seed[1])%len(bodies)] +
+		rightArms[int(seed[2])%len(rightArms)] +
+		accessories[int(seed[3])%len(accessories)]
+
+	return glyph, 31 + int(seed[4])%7
+}
+
+// showIdenticon reports whether the identicon should be written given the configured mode. In auto mode, it is only
+// shown when stderr is a terminal so scripts are left alone.
+func showIdenticon(ctx *cli.Context, mode string) (show, color bool, err error) {
+	terminal := false
+	if file, ok := ctx.App.ErrWriter.(*os.File); ok {
+		terminal = term.IsTerminal(int(file.Fd()))
+	}
+
+	switch mode {
+	case "", "auto":
+		return terminal, terminal, nil
+	case "always":
+		return true, terminal, nil
+	case "never":
+		return false, false, nil
+	}
+
+	return false, false, fmt.Errorf("unrecognized identicon mode: %s (use auto, always, or never)", mode)
+}
+
+// writeIdenticon prints the identicon for the identity to stderr.
+func writeIdenticon(ctx *cli.Context, mode string, user string, identity []byte) error {
+	show, color, err := showIdenticon(ctx, mode)
+	if err != nil || !show {
+		return err
+	}
+
+	glyph, code := Identicon(identity)
+	if color {
+		glyph = fmt.Sprintf("\x1b[%dm%s\x1b[0m", code, glyph)
+	}
+
+	_, err = fmt.Fprintf(ctx.App.ErrWriter, "identicon for %s: %s\n", user, glyph)
+
+	return err
+}
@@ -0,0 +1,111 @@
+// Copyright (C) 2022 Mya Pitzeruse
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pass
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+)
+
+// legacyFile is where site counters were stored before the vault. Entries are keyed by an hmac of the passphrase,
+// user, and site, so they can only be migrated once the site is known.
+const legacyFile = "pass-generations.json"
+
+// hashKey computes a dictionary key using the passphrase as an hmac seed, and the user and site as the hash key.
+func hashKey(passphrase, user, site string) string {
+	hmacKey := sha256.Sum256([]byte(passphrase))
+	hmac := hmac.New(sha256.New, hmacKey[:])
+
+	_, _ = hmac.Write([]byte(user + "@" + site))
+
+	return base64.URLEncoding.EncodeToString(hmac.Sum(nil))
+}
+
+func loadGenerations(directory string) (map[string]uint32, error) {
+	generations := make(map[string]uint32)
+
+	path := filepath.Join(directory, legacyFile)
+
+	data, err := readState(path)
+	if err != nil || data == nil {
+		return generations, err
+	}
+
+	if err = json.Unmarshal(data, &generations); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return generations, nil
+}
+
+func writeGenerations(directory string, generations map[string]uint32) error {
+	path := filepath.Join(directory, legacyFile)
+
+	if len(generations) == 0 {
+		return removeState(path)
+	}
+
+	data, err := json.Marshal(generations)
+	if err != nil {
+		return err
+	}
+
+	return writeState(path, data)
+}
+
+// migrate moves the counter for a site out of the legacy generations file and into the vault. The vault is saved
+// before the legacy entry is removed so an interrupted migration never loses a counter. Nil is returned when there is
+// nothing to migrate.
+func migrate(directory string, vault *Vault, passphrase, user, site, template string) (*Site, error) {
+	generations, err := loadGenerations(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	key := hashKey(passphrase, user, site)
+
+	counter, ok := generations[key]
+	if !ok {
+		return nil, nil
+	}
+
+	entry := &Site{
+		Name:     site,
+		User:     user,
+		Template: template,
+		Counter:  counter,
+	}
+
+	vault.Put(entry)
+	if err = vault.Save(); err != nil {
+		return nil, err
+	}
+
+	delete(generations, key)
+
+	return entry, writeGenerations(directory, generations)
+}
+
+// unmigrated returns the number of entries left in the legacy generations file.
+func unmigrated(directory string) (int, error) {
+	generations, err := loadGenerations(directory)
+
+	return len(generations), err
+}
@@ -0,0 +1,33 @@
+// Copyright (C) 2022 Mya Pitzeruse
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build unix
+// +build unix
+
+package pass
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// lockMemory prevents the memory backing data from being swapped to disk.
+func lockMemory(data []byte) error {
+	return unix.Mlock(data)
+}
+
+// unlockMemory releases a lock taken by lockMemory.
+func unlockMemory(data []byte) error {
+	return unix.Munlock(data)
+}
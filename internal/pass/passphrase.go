@@ -0,0 +1,115 @@
+// Copyright (C) 2022 Mya Pitzeruse
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pass
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/term"
+)
+
+// passphraseEnv is the environment variable the root passphrase is read from when no other source is provided.
+const passphraseEnv = "EM_PASSPHRASE"
+
+// PassphraseSource describes where the root passphrase should be read from.
+type PassphraseSource struct {
+	// Flag is the value of the --passphrase flag. Because flags are visible in shell history and process listings, it
+	// is only honored when AllowFlag is set.
+	Flag      string
+	AllowFlag bool
+	// FD is a file descriptor the passphrase is read from. Negative values disable it.
+	FD int
+}
+
+// explicit reports whether the passphrase was given specifically for this command, as opposed to being picked up
+// from the environment or a prompt.
+func (s PassphraseSource) explicit() bool {
+	return (s.Flag != "" && s.Flag != os.Getenv(passphraseEnv)) || s.FD >= 0
+}
+
+// readPassphrase resolves the root passphrase. Sources are checked in order: the --passphrase flag (when explicitly
+// allowed), a file descriptor, the EM_PASSPHRASE environment variable, and finally a prompt on the terminal.
+func readPassphrase(ctx *cli.Context, source PassphraseSource) (string, error) {
+	env := os.Getenv(passphraseEnv)
+
+	// the environment variable may also populate the flag, which is fine since it never appears in the process listing
+	if source.Flag != "" && source.Flag != env {
+		if !source.AllowFlag {
+			return "", fmt.Errorf("refusing to read the passphrase from --passphrase, which exposes it in shell " +
+				"history and process listings; enter it at the prompt, use --passphrase_fd or " + passphraseEnv +
+				", or pass --allow_insecure_passphrase")
+		}
+
+		return source.Flag, nil
+	}
+
+	if source.FD >= 0 {
+		return readPassphraseFD(source.FD)
+	}
+
+	if env != "" {
+		return env, nil
+	}
+
+	return promptPassphrase(ctx)
+}
+
+// readPassphraseFD reads the first line from the file descriptor.
+func readPassphraseFD(fd int) (string, error) {
+	file := os.NewFile(uintptr(fd), fmt.Sprintf("fd%d", fd))
+	if file == nil {
+		return "", fmt.Errorf("invalid passphrase file descriptor: %d", fd)
+	}
+
+	line, err := bufio.NewReader(file).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("failed to read passphrase from file descriptor %d: %w", fd, err)
+	}
+
+	passphrase := strings.TrimRight(line, "\r\n")
+	if passphrase == "" {
+		return "", fmt.Errorf("no passphrase read from file descriptor %d", fd)
+	}
+
+	return passphrase, nil
+}
+
+// promptPassphrase asks for the passphrase on the terminal without echoing it.
+func promptPassphrase(ctx *cli.Context) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("no passphrase provided; stdin is not a terminal, so use --passphrase_fd or " + passphraseEnv)
+	}
+
+	_, _ = fmt.Fprint(ctx.App.ErrWriter, "passphrase: ")
+	data, err := term.ReadPassword(fd)
+	_, _ = fmt.Fprintln(ctx.App.ErrWriter)
+
+	if err != nil {
+		return "", err
+	}
+
+	if len(data) == 0 {
+		return "", fmt.Errorf("passphrase must not be empty")
+	}
+
+	return string(data), nil
+}
@@ -0,0 +1,35 @@
+// Copyright (C) 2022 Mya Pitzeruse
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package pass
+
+import (
+	"fmt"
+	"net"
+	"os"
+)
+
+// fileOwner returns the uid of the user owning the file described by info.
+func fileOwner(info os.FileInfo) (int, error) {
+	return 0, fmt.Errorf("checking file ownership is not supported on this platform")
+}
+
+// peerUID returns the uid of the process on the other end of a unix socket.
+func peerUID(conn *net.UnixConn) (int, error) {
+	return 0, fmt.Errorf("checking peer credentials is not supported on this platform")
+}
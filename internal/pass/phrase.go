@@ -0,0 +1,84 @@
+// Copyright (C) 2022 Mya Pitzeruse
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pass
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// phraseContext separates derived phrases from the passwords derived for the same site.
+const phraseContext = "em/pass/phrase"
+
+// pick selects a uniformly distributed index below n using two bytes at a time from reader. Values that would bias
+// the result towards smaller indexes are rejected.
+func pick(reader io.Reader, n int) (int, error) {
+	limit := 1 << 16
+	limit -= limit % n
+
+	buf := make([]byte, 2)
+
+	for {
+		if _, err := io.ReadFull(reader, buf); err != nil {
+			return 0, err
+		}
+
+		if v := int(binary.BigEndian.Uint16(buf)); v < limit {
+			return v % n, nil
+		}
+	}
+}
+
+// phrase joins count words chosen from the wordlist using the randomness in reader.
+func phrase(reader io.Reader, words []string, count int, separator string) (string, error) {
+	if count < 1 {
+		return "", fmt.Errorf("at least one word is required")
+	}
+
+	chosen := make([]string, count)
+	for i := range chosen {
+		idx, err := pick(reader, len(words))
+		if err != nil {
+			return "", err
+		}
+
+		chosen[i] = words[idx]
+	}
+
+	return strings.Join(chosen, separator), nil
+}
+
+// randomPhrase chooses words using the system's secure random number generator.
+func randomPhrase(words []string, count int, separator string) (string, error) {
+	return phrase(rand.Reader, words, count, separator)
+}
+
+// derivedPhrase deterministically chooses words by expanding the site key.
+func derivedPhrase(siteKey []byte, words []string, count int, separator string) (string, error) {
+	return phrase(hkdf.Expand(sha256.New, siteKey, []byte(phraseContext)), words, count, separator)
+}
+
+// phraseEntropy returns the number of bits of entropy in a phrase of count words drawn from a list of size words.
+func phraseEntropy(size, count int) float64 {
+	return float64(count) * math.Log2(float64(size))
+}
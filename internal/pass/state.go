@@ -0,0 +1,145 @@
+// Copyright (C) 2022 Mya Pitzeruse
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gofrs/flock"
+)
+
+const (
+	// lockFile guards every read-modify-write of the pass state.
+	lockFile = "pass.lock"
+	// lockTimeout bounds how long a command waits on another em process before giving up.
+	lockTimeout = 10 * time.Second
+	// backupSuffix is appended to the name of a state file to hold its previous contents.
+	backupSuffix = ".bak"
+)
+
+// lockState takes an exclusive lock on the pass state in directory. The returned lock must be unlocked once the
+// command is done reading and writing state.
+func lockState(ctx context.Context, directory string) (*flock.Flock, error) {
+	lock := flock.New(filepath.Join(directory, lockFile))
+
+	ctx, cancel := context.WithTimeout(ctx, lockTimeout)
+	defer cancel()
+
+	locked, err := lock.TryLockContext(ctx, 50*time.Millisecond)
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), err == nil && !locked:
+		return nil, fmt.Errorf("timed out waiting for %s, is another em pass command running?", lock.Path())
+	case err != nil:
+		return nil, fmt.Errorf("failed to lock %s: %w", lock.Path(), err)
+	}
+
+	return lock, nil
+}
+
+// readState reads a state file. Missing files return nil data and no error, but files that exist and cannot be read
+// are reported rather than being mistaken for empty state.
+func readState(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("%s exists but could not be read, refusing to continue so its contents are not "+
+			"lost: %w", path, err)
+	}
+
+	return data, nil
+}
+
+// writeState atomically replaces the contents of path. Data is written to a temporary file in the same directory,
+// synced, and renamed into place. The previous contents are kept alongside it with a .bak suffix.
+func writeState(path string, data []byte) error {
+	previous, err := readState(path)
+	if err != nil {
+		return err
+	}
+
+	if previous != nil {
+		if err = replace(path+backupSuffix, previous); err != nil {
+			return fmt.Errorf("failed to back up %s: %w", path, err)
+		}
+	}
+
+	return replace(path, data)
+}
+
+// removeState removes a state file, keeping a backup of its final contents.
+func removeState(path string) error {
+	previous, err := readState(path)
+	if err != nil || previous == nil {
+		return err
+	}
+
+	if err = replace(path+backupSuffix, previous); err != nil {
+		return fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+
+	return os.Remove(path)
+}
+
+func replace(path string, data []byte) (err error) {
+	dir := filepath.Dir(path)
+
+	temp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = temp.Close()
+			_ = os.Remove(temp.Name())
+		}
+	}()
+
+	if err = temp.Chmod(0o600); err != nil {
+		return err
+	}
+
+	if _, err = temp.Write(data); err != nil {
+		return err
+	}
+
+	if err = temp.Sync(); err != nil {
+		return err
+	}
+
+	if err = temp.Close(); err != nil {
+		return err
+	}
+
+	if err = os.Rename(temp.Name(), path); err != nil {
+		return err
+	}
+
+	// sync the directory so the rename itself survives a crash
+	if handle, err := os.Open(dir); err == nil {
+		_ = handle.Sync()
+		_ = handle.Close()
+	}
+
+	return nil
+}
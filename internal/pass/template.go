@@ -0,0 +1,111 @@
+// Copyright (C) 2022 Mya Pitzeruse
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pass
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+
+	"go.pitz.tech/lib/pass"
+)
+
+// characterClasses maps each template character to the characters it may produce, following Master Password.
+var characterClasses = map[byte]string{
+	'V': "AEIOU",
+	'C': "BCDFGHJKLMNPQRSTVWXYZ",
+	'v': "aeiou",
+	'c': "bcdfghjklmnpqrstvwxyz",
+	'A': "AEIOUBCDFGHJKLMNPQRSTVWXYZ",
+	'a': "AEIOUaeiouBCDFGHJKLMNPQRSTVWXYZbcdfghjklmnpqrstvwxyz",
+	'n': "0123456789",
+	'o': "@&%?,=[]_:-+*$#!'^~;()/.",
+	'x': "AEIOUaeiouBCDFGHJKLMNPQRSTVWXYZbcdfghjklmnpqrstvwxyz0123456789!@#$%^&*()",
+	' ': " ",
+}
+
+// builtinPatterns mirror the patterns behind the pass library's template classes, which the library does not export,
+// so they can be checked against site policies. Built-in templates are always rendered by the library itself.
+var builtinPatterns = map[string][]string{
+	string(pass.MaximumSecurity): {"anoxxxxxxxxxxxxxxxxx", "axxxxxxxxxxxxxxxxxno"},
+	string(pass.Long): {
+		"CvcvnoCvcvCvcv", "CvcvCvcvnoCvcv", "CvcvCvcvCvcvno", "CvccnoCvcvCvcv", "CvccCvcvnoCvcv", "CvccCvcvCvcvno",
+		"CvcvnoCvccCvcv", "CvcvCvccnoCvcv", "CvcvCvccCvcvno", "CvcvnoCvcvCvcc", "CvcvCvcvnoCvcc", "CvcvCvcvCvccno",
+		"CvccnoCvccCvcv", "CvccCvccnoCvcv", "CvccCvccCvcvno", "CvcvnoCvccCvcc", "CvcvCvccnoCvcc", "CvcvCvccCvccno",
+		"CvccnoCvcvCvcc", "CvccCvcvnoCvcc", "CvccCvcvCvccno",
+	},
+	string(pass.Medium):           {"CvcnoCvc", "CvcCvcno"},
+	string(pass.Short):            {"Cvcn"},
+	string(pass.Basic):            {"aaanaaan", "aannaaan", "aaannaaa"},
+	string(pass.PIN):              {"nnnn"},
+	string(pass.VerificationCode): {"nnnnnn"},
+}
+
+// extendedTemplates are rendered by em rather than the pass library. The name and phrase templates match the ones
+// Master Password uses for login names and security answers.
+var extendedTemplates = map[string][]string{
+	"name":   {"cvccvcvcv"},
+	"phrase": {"cvcc cvc cvccvcv cvc", "cvc cvccvcvcv cvcv", "cv cvccv cvc cvcvccv"},
+}
+
+// maxPatternLength is the longest pattern a site key can fill in. The first byte of the key selects the pattern and
+// each remaining byte produces one character, so longer patterns would have to reuse bytes.
+const maxPatternLength = sha256.Size - 1
+
+// render picks one of the patterns using the first byte of the site key and fills it in with the remaining bytes,
+// drawing each character from the given classes. Patterns may be at most maxPatternLength characters long.
+func render(siteKey []byte, patterns []string, classes map[byte]string) []byte {
+	pattern := patterns[int(siteKey[0])%len(patterns)]
+	out := make([]byte, len(pattern))
+
+	for i := 0; i < len(pattern); i++ {
+		class := classes[pattern[i]]
+		out[i] = class[int(siteKey[i+1])%len(class)]
+	}
+
+	return out
+}
+
+// siteKey derives the key for a site. Without a context it defers to the pass library. With one, the context is
+// appended to the message the way Master Password scopes security answers to a specific question.
+func siteKey(scope pass.Scope, identity []byte, site string, counter uint32, context string) []byte {
+	if context == "" {
+		return pass.SiteKey(scope, identity, site, counter)
+	}
+
+	mac := hmac.New(sha256.New, identity)
+	_, _ = mac.Write([]byte(scope))
+	_, _ = mac.Write(binary.BigEndian.AppendUint32(nil, uint32(len(site))))
+	_, _ = mac.Write([]byte(site))
+	_, _ = mac.Write(binary.BigEndian.AppendUint32(nil, counter))
+	_, _ = mac.Write(binary.BigEndian.AppendUint32(nil, uint32(len(context))))
+	_, _ = mac.Write([]byte(context))
+
+	return mac.Sum(nil)
+}
+
+// purpose describes what a derived value is used for.
+type purpose struct {
+	scope    pass.Scope
+	template string
+}
+
+// purposes maps the --purpose flag to the scope used for derivation and the template used when none is given.
+var purposes = map[string]purpose{
+	"password": {scope: pass.Authentication, template: string(pass.MaximumSecurity)},
+	"login":    {scope: pass.Identification, template: "name"},
+	"recovery": {scope: pass.Recovery, template: "phrase"},
+}
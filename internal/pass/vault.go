@@ -0,0 +1,215 @@
+// Copyright (C) 2022 Mya Pitzeruse
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pass
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+const (
+	vaultVersion = 1
+	vaultPrefix  = "pass-vault-"
+)
+
+// Site records everything needed to derive the password for a site, except for the root passphrase.
+type Site struct {
+	Name      string    `json:"name"`
+	User      string    `json:"user"`
+	Template  string    `json:"template"`
+	Counter   uint32    `json:"counter"`
+	Notes     string    `json:"notes,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// History lists the counters the site has moved through, ending with the current one.
+	History []Generation `json:"history,omitempty"`
+}
+
+// Generation records when a site moved to a counter.
+type Generation struct {
+	Counter uint32    `json:"counter"`
+	At      time.Time `json:"at"`
+}
+
+// seedHistory starts the history for sites created before it was recorded.
+func (s *Site) seedHistory() {
+	if len(s.History) > 0 {
+		return
+	}
+
+	at := s.CreatedAt
+	if at.IsZero() {
+		at = time.Now().UTC()
+	}
+
+	s.History = []Generation{{Counter: s.Counter, At: at}}
+}
+
+// SetCounter moves the site to the counter, recording the change in its history.
+func (s *Site) SetCounter(counter uint32) {
+	s.seedHistory()
+
+	s.Counter = counter
+	s.History = append(s.History, Generation{Counter: counter, At: time.Now().UTC()})
+}
+
+// Undo returns the site to the counter it used before its most recent change. The abandoned generation is removed
+// from the history, so repeated calls keep walking backwards.
+func (s *Site) Undo() error {
+	s.seedHistory()
+
+	if len(s.History) < 2 {
+		return fmt.Errorf("%s has no earlier counter to return to", s.Name)
+	}
+
+	s.History = s.History[:len(s.History)-1]
+	s.Counter = s.History[len(s.History)-1].Counter
+
+	return nil
+}
+
+// envelope is the on-disk representation of a vault.
+type envelope struct {
+	Version int    `json:"version"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+// Vault is the encrypted collection of sites belonging to a single identity. Each identity (passphrase and user) has
+// its own vault file, named after a fingerprint of the identity, so vaults never reveal the sites they contain.
+type Vault struct {
+	Sites map[string]*Site `json:"sites"`
+
+	path string
+	key  []byte
+}
+
+func deriveVaultKey(identity []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, identity)
+	_, _ = mac.Write([]byte("em/pass/vault/" + purpose))
+
+	return mac.Sum(nil)
+}
+
+// OpenVault decrypts the vault for the identity stored in directory. A missing vault results in an empty one. Callers
+// should hold the state lock while the vault is open.
+func OpenVault(directory string, identity []byte) (*Vault, error) {
+	id := deriveVaultKey(identity, "id")
+
+	vault := &Vault{
+		Sites: make(map[string]*Site),
+		path:  filepath.Join(directory, vaultPrefix+hex.EncodeToString(id[:8])+".json"),
+		key:   deriveVaultKey(identity, "key"),
+	}
+
+	data, err := readState(vault.path)
+	if err != nil || data == nil {
+		return vault, err
+	}
+
+	env := envelope{}
+	if err = json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("failed to read vault %s: %w", vault.path, err)
+	}
+
+	if env.Version != vaultVersion {
+		return nil, fmt.Errorf("unsupported vault version: %d", env.Version)
+	}
+
+	aead, err := chacha20poly1305.NewX(vault.key)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := aead.Open(nil, env.Nonce, env.Data, []byte(vaultPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt vault %s: %w", vault.path, err)
+	}
+
+	if err = json.Unmarshal(plaintext, vault); err != nil {
+		return nil, fmt.Errorf("failed to read vault %s: %w", vault.path, err)
+	}
+
+	return vault, nil
+}
+
+// Save encrypts and writes the vault back to disk.
+func (v *Vault) Save() error {
+	plaintext, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	aead, err := chacha20poly1305.NewX(v.key)
+	if err != nil {
+		return err
+	}
+
+	env := envelope{
+		Version: vaultVersion,
+		Nonce:   make([]byte, aead.NonceSize()),
+	}
+
+	if _, err = rand.Read(env.Nonce); err != nil {
+		return err
+	}
+
+	env.Data = aead.Seal(nil, env.Nonce, plaintext, []byte(vaultPrefix))
+
+	data, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+
+	return writeState(v.path, data)
+}
+
+// Put adds or replaces a site, maintaining its timestamps.
+func (v *Vault) Put(site *Site) {
+	now := time.Now().UTC()
+
+	if site.CreatedAt.IsZero() {
+		site.CreatedAt = now
+	}
+
+	site.UpdatedAt = now
+	site.seedHistory()
+	v.Sites[site.Name] = site
+}
+
+// List returns the sites in the vault sorted by name.
+func (v *Vault) List() []*Site {
+	sites := make([]*Site, 0, len(v.Sites))
+	for _, site := range v.Sites {
+		sites = append(sites, site)
+	}
+
+	sort.Slice(sites, func(i, j int) bool {
+		return sites[i].Name < sites[j].Name
+	})
+
+	return sites
+}
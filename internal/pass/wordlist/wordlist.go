@@ -0,0 +1,83 @@
+// Copyright (C) 2022 Mya Pitzeruse
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package wordlist embeds the EFF diceware wordlists. The lists are published by the Electronic Frontier Foundation
+// under CC BY 3.0 US, see https://www.eff.org/deeplinks/2016/07/new-wordlists-random-passphrases.
+package wordlist
+
+import (
+	"bufio"
+	_ "embed"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	//go:embed eff_large_wordlist.txt
+	effLarge string
+
+	//go:embed eff_short_wordlist_2_0.txt
+	effShort string
+
+	sources = map[string]string{
+		"eff-large": effLarge,
+		"eff-short": effShort,
+	}
+
+	parsed = make(map[string][]string)
+	mu     sync.Mutex
+)
+
+// Names returns the names of the available wordlists.
+func Names() []string {
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Lookup returns the words in the named list, in dice roll order.
+func Lookup(name string) ([]string, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if words, ok := parsed[name]; ok {
+		return words, true
+	}
+
+	source, ok := sources[name]
+	if !ok {
+		return nil, false
+	}
+
+	// each line holds the dice roll and the word, separated by a tab
+	var words []string
+
+	scanner := bufio.NewScanner(strings.NewReader(source))
+	for scanner.Scan() {
+		if _, word, ok := strings.Cut(scanner.Text(), "\t"); ok {
+			words = append(words, word)
+		}
+	}
+
+	parsed[name] = words
+
+	return words, true
+}
@@ -24,8 +24,11 @@ import (
 
 	"go.pitz.tech/em/internal/admin"
 	"go.pitz.tech/em/internal/ballistics"
+	"go.pitz.tech/em/internal/checksum"
+	"go.pitz.tech/em/internal/convert"
 	"go.pitz.tech/em/internal/crypto"
 	"go.pitz.tech/em/internal/encoding"
+	"go.pitz.tech/em/internal/jwt"
 	"go.pitz.tech/em/internal/oidc"
 	"go.pitz.tech/em/internal/pass"
 	"go.pitz.tech/em/internal/project"
@@ -58,8 +61,11 @@ func main() {
 			// order package by abc
 			admin.Command,
 			ballistics.Command,
+			checksum.Command,
+			convert.Command,
 			crypto.Command,
 			encoding.Command,
+			jwt.Command,
 			oidc.Command,
 			pass.Command,
 			project.Command,